@@ -1,62 +1,60 @@
 package main
 
 import (
+	"errors"
 	"fmt"
 	lib "github.com/c4s4/changelog/lib"
+	"io"
 	"os"
-	"strconv"
-	"strings"
 )
 
 func printError(err error) {
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "ERROR: %s\n", err.Error())
+		var usage *lib.UsageError
+		if errors.As(err, &usage) {
+			if usage.Usage != "" {
+				fmt.Fprintf(os.Stderr, "Usage: %s\n", usage.Usage)
+			}
+			os.Exit(2)
+		}
 		os.Exit(1)
 	}
 }
 
 func main() {
-	var changelog lib.Changelog
-	var command string
-	var args []string
-	if len(os.Args) < 2 {
-		command = lib.HelpCommand
-	} else {
-		var source []byte
-		var err error
-		if lib.IsPiped() {
-			source, err = lib.ReadStdin()
+	options, command, args, err := lib.ParseCommandLine(os.Args[1:])
+	printError(err)
+	context := lib.Context{Options: options, Out: os.Stdout, Err: os.Stderr}
+	if options.Quiet {
+		context.Out = io.Discard
+	}
+	if !command.NoChangelog {
+		var config *lib.Config
+		if lib.IsPiped() && options.File == "" {
+			context.Source, err = lib.ReadStdin()
+			printError(err)
+			config, err = lib.LoadConfig(".")
 			printError(err)
 		} else {
-			file, err := lib.FindChangelog()
+			context.File, config, err = lib.FindChangelog(options.File)
 			printError(err)
-			source, err = lib.ReadChangelog(file)
+			context.Source, err = lib.ReadChangelog(context.File)
 			printError(err)
 		}
-		changelog, err = lib.ParseChangelog(source)
-		printError(err)
-		command = os.Args[1]
-		if command == "next" {
-			command = "-1"
-		}
-		if strings.HasPrefix(command, "-") {
-			delta, err := strconv.Atoi(command[1:])
-			if err != nil || delta >= len(changelog) {
-				printError(fmt.Errorf("bad shift '%s'", command))
-			}
-			changelog = changelog[delta:]
-			command = os.Args[2]
-			args = os.Args[3:]
-		} else {
-			args = os.Args[2:]
+		printError(config.Apply())
+		context.Changelog, err = lib.ParseChangelog(context.Source)
+		// lenient commands report parsing errors themselves
+		if !command.Lenient {
+			printError(err)
 		}
+		printError(context.SelectReleases())
 	}
-	function := lib.CommandMapping[command]
-	if function != nil {
-		if err := function(changelog, args); err != nil {
-			printError(fmt.Errorf("running command: %v", err))
+	if err := command.Run(&context, args); err != nil {
+		var usage *lib.UsageError
+		if errors.As(err, &usage) && usage.Usage == "" {
+			usage.Usage = command.UsageLine()
 		}
-	} else {
-		printError(fmt.Errorf("command '%s' unknown", command))
+		printError(fmt.Errorf("running command: %w", err))
 	}
 }
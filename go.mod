@@ -2,4 +2,4 @@ module github.com/c4s4/changelog
 
 go 1.16
 
-require gopkg.in/yaml.v2 v2.2.1
+require gopkg.in/yaml.v3 v3.0.1
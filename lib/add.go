@@ -0,0 +1,26 @@
+package lib
+
+import (
+	"fmt"
+	"strings"
+)
+
+func add(context *Context, args []string) error {
+	if len(args) < 2 {
+		return usageError("you must pass section and entry text")
+	}
+	section := strings.ToLower(args[0])
+	if sectionIndex(section) < 0 {
+		return usageError("unknown section %s (must be one of %s)", args[0],
+			strings.Join(Sections, ", "))
+	}
+	entry := strings.Join(args[1:], " ")
+	document, err := context.Document()
+	if err != nil {
+		return err
+	}
+	if err := document.AddEntry(context.Shift, section, entry); err != nil {
+		return fmt.Errorf("adding entry: %v", err)
+	}
+	return context.Write(document)
+}
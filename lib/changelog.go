@@ -1,72 +1,362 @@
 package lib
 
 import (
+	"bytes"
+	"encoding/json"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"os"
 	"path/filepath"
 	"regexp"
+	"strings"
 
-	"gopkg.in/yaml.v2"
+	"gopkg.in/yaml.v3"
 )
 
-// Command is a changelog command implemented with a function
-type Command func(Changelog, []string) error
+// Context is the context a command runs in
+type Context struct {
+	// Options are the global command line options
+	Options *Options
+	// Out is where command prints its output
+	Out io.Writer
+	// Err is where command prints warnings
+	Err io.Writer
+	// Changelog is the parsed changelog, shifted with -N on command line
+	Changelog Changelog
+	// Source is the changelog source
+	Source []byte
+	// File is the changelog file, empty if changelog was piped
+	File string
+	// Shift is the number of releases skipped with -N on command line
+	Shift int
+}
 
-// CommandMapping maps command names with command functions
-var CommandMapping = map[string]Command{
-	"Help":    Help,
-	"release": release,
-	"to":      transform,
+// SelectReleases applies release shift and selection of options to changelog
+func (c *Context) SelectReleases() error {
+	shift := c.Options.Release
+	// releases are counted from last release, after unreleased changes
+	if shift > 0 && len(c.Changelog) > 0 && c.Changelog[0].Unreleased() {
+		shift++
+	}
+	if shift > 0 {
+		if shift >= len(c.Changelog) {
+			return fmt.Errorf("bad shift '-%d'", c.Options.Release)
+		}
+		c.Changelog = c.Changelog[shift:]
+	}
+	if !c.Options.Selection.Empty() {
+		selected, err := c.Options.Selection.Select(c.Changelog)
+		if err != nil {
+			return err
+		}
+		// shift becomes the index of first selected release
+		for index, release := range c.Changelog {
+			if release.Version == selected[0].Version {
+				shift += index
+				break
+			}
+		}
+		c.Changelog = selected
+	}
+	c.Shift = shift
+	return nil
 }
 
-// Release contains information about a release
-type Release struct {
-	Version    string
-	Date       string
-	Summary    string
-	Added      []string
-	Changed    []string
-	Deprecated []string
-	Removed    []string
-	Fixed      []string
-	Security   []string
-	Rejected   []string
-	Notes      []string
+// Document parses context source into a document
+func (c *Context) Document() (*Document, error) {
+	return ParseDocument(c.Source)
 }
 
-// Changelog is a list of releases
-type Changelog []Release
+// Write writes document to changelog file
+func (c *Context) Write(document *Document) error {
+	if c.File == "" {
+		return fmt.Errorf("can't edit a piped changelog")
+	}
+	return WriteChangelog(c.File, document.Bytes())
+}
 
-const (
-	// HelpMessage is the help text
-	HelpMessage = `Manage semantic changelog
+// CommandMapping maps command names with commands
+var CommandMapping = map[string]*Command{
+	"add": {
+		Name:        "add",
+		Usage:       "section text",
+		Description: "Add an entry to a section of release",
+		Help: `Section is one of configured sections, by default added, changed,
+deprecated, removed, fixed, security, rejected or notes, and is created if
+necessary. Entry text is made of all remaining arguments.`,
+		Run: add,
+	},
+	"check": {
+		Name:        "check",
+		Usage:       "[tags]",
+		Description: "Check changelog strictly, reporting all errors",
+		Help: `Reports unknown and duplicate keys, sections that are not lists, bad
+versions and dates, and releases out of order, with their line and column.
+With --format json, errors are printed as a JSON list.
 
-  changelog                        Print this Help screen
-  changelog release                Check for release
+With tags argument, compares releases with git tags (with prefix set in
+repository configuration): reports releases without tag, tags without
+release and tag dates that differ from release dates by more than tolerance
+days of repository configuration.`,
+		Run:     check,
+		Lenient: true,
+	},
+	"fmt": {
+		Name:        "fmt",
+		Usage:       "[--check] [--diff]",
+		Description: "Format changelog in normalized YAML",
+		Help: `Rewrites changelog file with fields in canonical order, aligned header fields
+and quoting where necessary. Comments on top of file are kept, others are
+lost. A piped changelog is printed formatted.
+
+  --check  Don't write changelog, fail if it is not formatted
+  --diff   Don't write changelog, print changes as a unified diff`,
+		Run:   format,
+		Flags: []string{"check", "diff"},
+	},
+	"generate": {
+		Name:        "generate",
+		Usage:       "[--from ref] [--to ref] [--merge]",
+		Description: "Generate release entries from Conventional Commits",
+		Help: `Reads git history between --from reference (defaults to tag of top release
+if it exists, or whole history) and --to reference (defaults to HEAD), and
+groups commits by type: feat in added, fix in fixed, perf in changed,
+remove in removed and security in security. Breaking changes, marked with
+'!' or a 'BREAKING CHANGE:' footer, are prefixed with 'BREAKING: '. Other
+commits are ignored.
+
+Prints a draft release, with version bumped according to its entries (see
+'release suggest'), or merges entries into top release with --merge.`,
+		Run:   generate,
+		Flags: []string{"from=", "to=", "merge"},
+	},
+	"import": {
+		Name:        "import",
+		Usage:       "markdown file",
+		Description: "Import a Keep a Changelog markdown file",
+		Help: `Parses release headings such as '## [1.2.0] - 2024-01-01', with their
+subsections such as '### Added', and prints changelog in YAML, or writes it
+to file given with --file option. Unreleased section becomes an unreleased
+release, link reference definitions are ignored and lines that could not be
+mapped are reported.`,
+		Run:         importChangelog,
+		NoChangelog: true,
+	},
+	"new": {
+		Name:        "new",
+		Usage:       "[level|version|unreleased]",
+		Description: "Add a new release on top of changelog",
+		Help: `Version of new release is given, or computed from top release version with
+level, which is major, minor, patch (default), or a pre-release suffix such
+as snapshot, alpha, beta or rc. Release date is today. With unreleased, adds
+a release without version nor date to gather changes, to be released with
+'release cut'.`,
+		Run: newRelease,
+	},
+	"release": {
+		Name:        "release",
+		Usage:       "[date [check]|version|summary|suggest|cut [version] [--tag] [--dry-run]|to markdown|desc markdown]",
+		Description: "Check for release and print release information",
+		Help: `  changelog release                Check for release
   changelog release date           Print release date
-  changelog release date check     Check that release date wright
+  changelog release date check     Check that release date is today
   changelog release version        Print release version
   changelog release summary        Print release summary
+  changelog release suggest        Print version suggested by release changes
+  changelog release cut [version]  Strip version suffix and set date to today
   changelog release to markdown    Print release changelog in markdown
   changelog release desc markdown  Print release changelog description in markdown
-  changelog to html                Transform changelog to html
-  changelog to html stylesheet     Transform to html with a stylesheet
-                                   ('style' uses a default stylesheet)
-  changelog to markdown            Transform changelog to markdown
+  changelog --json release         Print release in JSON
 
-You can add 'next' after changelog command to consider next to last release
-instead of the last, or '-N' go go back in past Nth release.
+Information is about last release, skipping an unreleased release on top.
+Command cut gives unreleased release the version passed, or the version
+suggested by its changes. Option --tag of cut commits changelog and creates
+an annotated git tag with release in markdown as message. Option --dry-run
+prints changes as a diff. Option --template of to, desc and cut uses a
+template file instead of builtin release template.`,
+		Run:   release,
+		Flags: []string{"tag", "dry-run", "template="},
+	},
+	"to": {
+		Name:        "to",
+		Usage:       "[html [stylesheet...]|markdown|keepachangelog|json|yaml|template file] [--template file]",
+		Description: "Transform changelog to another format",
+		Help: `Format is html, markdown, keepachangelog, json or normalized yaml, and
+defaults to --format option or format of project configuration. With html
+format, you can pass stylesheet files to include in page ('style' uses a
+default stylesheet). Format keepachangelog is markdown following Keep a
+Changelog, with compare links if repository is configured.
 
-The changelog file is searched in current directory. To use a different
-changelog, use < character with its path:
+Format template renders changelog with a Go template file, escaping HTML if
+file extension is .html (or .html.tmpl). Option --template replaces builtin
+template of html, markdown or keepachangelog format with a template file.
+Templates can use functions date, compare, prerelease, sections and
+markdown (see README).`,
+		Run:   transform,
+		Flags: []string{"template="},
+	},
+}
 
-  changelog release < path/to/changelog.yml
+func init() {
+	// help is registered here as it refers to CommandMapping
+	CommandMapping[HelpCommand] = &Command{
+		Name:        HelpCommand,
+		Usage:       "[command]",
+		Description: "Print this help screen, or help about a command",
+		Run:         help,
+		NoChangelog: true,
+	}
+}
 
-will check for release a changelog in 'path/to' directory.`
-	// HelpCommand is the command for help
-	HelpCommand = "Help"
-)
+// Release contains information about a release
+type Release struct {
+	Version string
+	Date    string
+	Summary string
+	// Sections are the non empty sections of release, in configured order
+	Sections []Section
+}
+
+// Section is a section of release, such as added or fixed, with its entries
+type Section struct {
+	// Name is the name of section in changelog, such as added
+	Name string
+	// Entries are the entries of section
+	Entries []string
+}
+
+// Title returns the title of section, such as Added
+func (s Section) Title() string {
+	return SectionTitle(s.Name)
+}
+
+// DefaultSections are the names of release sections allowed by default
+var DefaultSections = []string{"added", "changed", "deprecated", "removed", "fixed",
+	"security", "rejected", "notes"}
+
+// Sections are the names of allowed release sections, in configured order
+var Sections = DefaultSections
+
+// SectionTitles are the titles of sections by name, set in configuration
+var SectionTitles = map[string]string{}
+
+// SectionTitle returns the title of section with given name: configured one,
+// or name with first letter in upper case
+func SectionTitle(name string) string {
+	if title, ok := SectionTitles[name]; ok {
+		return title
+	}
+	if name == "" {
+		return name
+	}
+	return strings.ToUpper(name[:1]) + name[1:]
+}
+
+// Section returns the entries of release section with given name
+func (r Release) Section(name string) []string {
+	for _, section := range r.Sections {
+		if section.Name == name {
+			return section.Entries
+		}
+	}
+	return nil
+}
+
+// Add appends entries to release section with given name. The section is
+// created at its configured position if it doesn't exist.
+func (r *Release) Add(name string, entries ...string) {
+	for i := range r.Sections {
+		if r.Sections[i].Name == name {
+			r.Sections[i].Entries = append(r.Sections[i].Entries, entries...)
+			return
+		}
+	}
+	position := len(r.Sections)
+	if index := sectionIndex(name); index >= 0 {
+		for i, section := range r.Sections {
+			if other := sectionIndex(section.Name); other < 0 || other > index {
+				position = i
+				break
+			}
+		}
+	}
+	sections := append([]Section{}, r.Sections[:position]...)
+	sections = append(sections, Section{Name: name, Entries: entries})
+	r.Sections = append(sections, r.Sections[position:]...)
+}
+
+// UnmarshalYAML decodes a release from a YAML map: header fields, and allowed
+// sections as lists of entries. Other keys are ignored as they are reported
+// by check command.
+func (r *Release) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind != yaml.MappingNode {
+		return fmt.Errorf("line %d: release must be a map", node.Line)
+	}
+	*r = Release{}
+	seen := make(map[string]int)
+	for i := 0; i < len(node.Content); i += 2 {
+		key, value := node.Content[i], node.Content[i+1]
+		if line, ok := seen[key.Value]; ok {
+			return fmt.Errorf("line %d: mapping key %q already defined at line %d", key.Line, key.Value, line)
+		}
+		seen[key.Value] = key.Line
+		var err error
+		switch {
+		case key.Value == "version":
+			err = value.Decode(&r.Version)
+		case key.Value == "date":
+			err = value.Decode(&r.Date)
+		case key.Value == "summary":
+			err = value.Decode(&r.Summary)
+		case sectionIndex(key.Value) >= 0:
+			var entries []string
+			if err = value.Decode(&entries); err == nil && len(entries) > 0 {
+				r.Add(key.Value, entries...)
+			}
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// MarshalJSON encodes release with header fields, then all allowed sections
+// as lists, empty ones included, in configured order
+func (r Release) MarshalJSON() ([]byte, error) {
+	names := append([]string{}, HeaderFields...)
+	values := []interface{}{r.Version, r.Date, r.Summary}
+	for _, name := range Sections {
+		entries := r.Section(name)
+		if entries == nil {
+			entries = []string{}
+		}
+		names = append(names, name)
+		values = append(values, entries)
+	}
+	var buffer bytes.Buffer
+	encoder := json.NewEncoder(&buffer)
+	encoder.SetEscapeHTML(false)
+	buffer.WriteString("{")
+	for i, name := range names {
+		if i > 0 {
+			buffer.WriteString(",")
+		}
+		if err := encoder.Encode(name); err != nil {
+			return nil, err
+		}
+		buffer.WriteString(":")
+		if err := encoder.Encode(values[i]); err != nil {
+			return nil, err
+		}
+	}
+	buffer.WriteString("}")
+	return buffer.Bytes(), nil
+}
+
+// Changelog is a list of releases
+type Changelog []Release
 
 // RegexpFilename is the regular expression for changelog filename
 var RegexpFilename = regexp.MustCompile(`^(?i)change(-|_)?log(.yml|.yaml)?$`)
@@ -89,18 +379,68 @@ func ReadStdin() ([]byte, error) {
 	return source, nil
 }
 
-// FindChangelog finds source file and return its name
-func FindChangelog() (string, error) {
-	files, err := ioutil.ReadDir(".")
+// FindChangelog finds changelog file and loads its configuration. If file is
+// not empty, this is the changelog file. Otherwise changelog is searched in
+// current directory and its parents, up to the root of the Git repository.
+// In each directory, changelog file might be set in configuration file.
+func FindChangelog(file string) (string, *Config, error) {
+	if file != "" {
+		config, err := LoadConfig(filepath.Dir(file))
+		if err != nil {
+			return "", nil, err
+		}
+		return file, config, nil
+	}
+	cwd, err := os.Getwd()
 	if err != nil {
-		return "", fmt.Errorf("could not list current directory")
+		return "", nil, fmt.Errorf("getting current directory: %v", err)
+	}
+	for dir := cwd; ; dir = filepath.Dir(dir) {
+		file, config, err := findChangelogInDir(dir)
+		if err != nil {
+			return "", nil, err
+		}
+		if file != "" {
+			if relative, err := filepath.Rel(cwd, file); err == nil {
+				file = relative
+			}
+			return file, config, nil
+		}
+		if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil || filepath.Dir(dir) == dir {
+			break
+		}
 	}
+	return "", nil, fmt.Errorf("could not find changelog file")
+}
+
+// findChangelogInDir looks for changelog file in given directory, returning
+// an empty file name if not found
+func findChangelogInDir(dir string) (string, *Config, error) {
+	config, err := LoadConfig(dir)
+	if err != nil {
+		return "", nil, err
+	}
+	if config.File != "" {
+		return filepath.Join(dir, config.File), config, nil
+	}
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return "", nil, fmt.Errorf("could not list directory '%s'", dir)
+	}
+	var found []string
 	for _, file := range files {
 		if !file.IsDir() && RegexpFilename.MatchString(file.Name()) {
-			return file.Name(), nil
+			found = append(found, file.Name())
 		}
 	}
-	return "", fmt.Errorf("could not find changelog file")
+	if len(found) > 1 {
+		return "", nil, fmt.Errorf("found several changelog files in directory '%s': %s",
+			dir, strings.Join(found, ", "))
+	}
+	if len(found) == 0 {
+		return "", nil, nil
+	}
+	return filepath.Join(dir, found[0]), config, nil
 }
 
 // ReadChangelog reads source file and return contents as array of bytes
@@ -112,6 +452,14 @@ func ReadChangelog(file string) ([]byte, error) {
 	return source, nil
 }
 
+// WriteChangelog writes source to changelog file
+func WriteChangelog(file string, source []byte) error {
+	if err := ioutil.WriteFile(filepath.Clean(file), source, 0644); err != nil {
+		return fmt.Errorf("writing changelog file '%s'", file)
+	}
+	return nil
+}
+
 // ParseChangelog parses source file and return Changelog object
 func ParseChangelog(source []byte) (Changelog, error) {
 	var changelog Changelog
@@ -121,10 +469,3 @@ func ParseChangelog(source []byte) (Changelog, error) {
 	}
 	return changelog, nil
 }
-
-// Help print help and exit
-func Help(changelog Changelog, args []string) error {
-	fmt.Println(HelpMessage)
-	os.Exit(0)
-	return nil
-}
@@ -1,6 +1,12 @@
 package lib
 
 import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -59,3 +65,92 @@ func TestVersionRegexp(t *testing.T) {
 		}
 	}
 }
+
+func TestFindChangelog(t *testing.T) {
+	root := t.TempDir()
+	sub := filepath.Join(root, "sub", "dir")
+	for _, dir := range []string{filepath.Join(root, ".git"), sub} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	cwd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(cwd)
+	if err := os.Chdir(sub); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := FindChangelog(""); err == nil {
+		t.Errorf("Changelog should not be found")
+	}
+	if err := ioutil.WriteFile(filepath.Join(root, "CHANGELOG.yml"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	file, _, err := FindChangelog("")
+	if err != nil || file != filepath.Join("..", "..", "CHANGELOG.yml") {
+		t.Errorf("Bad changelog file %s: %v", file, err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(root, "changelog.yaml"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := FindChangelog(""); err == nil || !strings.Contains(err.Error(), "several changelog files") {
+		t.Errorf("Several changelog files should be an error: %v", err)
+	}
+	file, _, err = FindChangelog("other.yml")
+	if err != nil || file != "other.yml" {
+		t.Errorf("Explicit changelog file should be used: %s, %v", file, err)
+	}
+}
+
+func TestCustomSections(t *testing.T) {
+	sections, titles := Sections, SectionTitles
+	defer func() { Sections, SectionTitles = sections, titles }()
+	Sections = []string{"performance", "added", "docs"}
+	SectionTitles = map[string]string{"performance": "Performance improvements"}
+	changelog, err := ParseChangelog([]byte(`- version: 1.0.0
+  date:    2024-01-01
+  docs:
+  - Guide
+  unknown:
+  - Ignored
+  added:
+  - Feature
+  performance:
+  - Faster
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []Section{{"performance", []string{"Faster"}}, {"added", []string{"Feature"}},
+		{"docs", []string{"Guide"}}}
+	if !reflect.DeepEqual(changelog[0].Sections, expected) {
+		t.Errorf("Bad sections: %v", changelog[0].Sections)
+	}
+	if title := changelog[0].Sections[0].Title(); title != "Performance improvements" {
+		t.Errorf("Bad configured title: %s", title)
+	}
+	if title := changelog[0].Sections[2].Title(); title != "Docs" {
+		t.Errorf("Bad default title: %s", title)
+	}
+	release := Release{Version: "1.1.0"}
+	release.Add("docs", "Other guide")
+	release.Add("performance", "Faster again")
+	release.Add("docs", "Last guide")
+	expected = []Section{{"performance", []string{"Faster again"}},
+		{"docs", []string{"Other guide", "Last guide"}}}
+	if !reflect.DeepEqual(release.Sections, expected) {
+		t.Errorf("Bad added sections: %v", release.Sections)
+	}
+	var out bytes.Buffer
+	if err := releaseToMarkdown(&out, release, ""); err != nil {
+		t.Fatal(err)
+	}
+	if out.String() != "\n\n# Performance improvements\n\n- Faster again\n\n# Docs\n\n- Other guide\n- Last guide\n" {
+		t.Errorf("Bad release markdown:\n%q", out.String())
+	}
+	if _, err := ParseChangelog([]byte("- version: 1.0.0\n  docs: []\n  docs: []\n")); err == nil {
+		t.Errorf("Duplicate sections should fail")
+	}
+}
@@ -0,0 +1,336 @@
+package lib
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Command is a changelog command
+type Command struct {
+	// Name is the name of command on command line
+	Name string
+	// Usage is the synopsis of command arguments
+	Usage string
+	// Description is a one line description of command
+	Description string
+	// Help is a detailed help about command, optional
+	Help string
+	// Run runs the command with its arguments
+	Run func(*Context, []string) error
+	// NoChangelog tells that command doesn't need a changelog
+	NoChangelog bool
+	// Lenient tells that command runs on changelog that can't be parsed
+	Lenient bool
+	// Flags are the names of options of command, passed with its arguments.
+	// Names ending with '=' are options with a value.
+	Flags []string
+}
+
+// UsageLine returns command usage line
+func (c *Command) UsageLine() string {
+	return strings.TrimSpace("changelog [options] " + c.Name + " " + c.Usage)
+}
+
+// Options are global command line options
+type Options struct {
+	// File is the path of changelog file
+	File string
+	// Format is the output format
+	Format string
+	// JSON sets output format to json
+	JSON bool
+	// Release is the number of releases to skip from top of changelog
+	Release int
+	// Selection selects releases to consider
+	Selection Selection
+	// Quiet disables output of commands
+	Quiet bool
+	// Help prints help about command
+	Help bool
+}
+
+// UsageError is an error in command line usage
+type UsageError struct {
+	// Message is the error message
+	Message string
+	// Usage is the usage line of command, if known
+	Usage string
+}
+
+// Error returns error message
+func (e *UsageError) Error() string {
+	return e.Message
+}
+
+// usageError returns a usage error for current command
+func usageError(format string, args ...interface{}) error {
+	return &UsageError{Message: fmt.Sprintf(format, args...)}
+}
+
+// commandLineError returns a usage error with general usage
+func commandLineError(format string, args ...interface{}) error {
+	return &UsageError{Message: fmt.Sprintf(format, args...), Usage: GeneralUsage}
+}
+
+const (
+	// HelpCommand is the command for help
+	HelpCommand = "help"
+	// GeneralUsage is the usage line of changelog tool
+	GeneralUsage = "changelog [options] command [arguments]"
+	// HelpHeader is the header of help screen
+	HelpHeader = "Manage semantic changelog"
+	// HelpFooter is the footer of help screen
+	HelpFooter = `Options are given before command, except options of the command itself.
+You can add 'next' before command to consider next to last release instead
+of the last, or '-N' to go back in past Nth release (same as --release N).
+
+The changelog file is searched in current directory and its parents, up to
+the root of the Git repository. To use a different changelog, pass its path
+with --file option, or use < character with its path:
+
+  changelog --file path/to/changelog.yml release
+  changelog release < path/to/changelog.yml
+
+will check for release a changelog in 'path/to' directory.
+
+Project configuration is read from '.changelog.yml' file in the changelog
+directory, if any.`
+)
+
+// regexpShift matches -N shift on command line
+var regexpShift = regexp.MustCompile(`^-\d+$`)
+
+// newFlagSet returns flag set for global options
+func newFlagSet(options *Options) *flag.FlagSet {
+	flags := flag.NewFlagSet("changelog", flag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+	flags.StringVar(&options.File, "file", "", "Path of changelog `file`")
+	flags.StringVar(&options.Format, "format", "", "Output `format`")
+	flags.BoolVar(&options.JSON, "json", false, "Output in JSON (same as --format json)")
+	flags.IntVar(&options.Release, "release", 0, "Consider `N`th release before last one")
+	flags.StringVar(&options.Selection.Version, "version", "", "Select release with `version`")
+	flags.StringVar(&options.Selection.Since, "since", "", "Select releases after `version`")
+	flags.StringVar(&options.Selection.Until, "until", "", "Select releases up to `version`")
+	flags.StringVar(&options.Selection.FromDate, "from-date", "", "Select releases from `date`")
+	flags.BoolVar(&options.Selection.LatestStable, "latest-stable", false, "Skip pre-releases on top of changelog")
+	flags.BoolVar(&options.Quiet, "quiet", false, "Don't print command output")
+	flags.BoolVar(&options.Help, "help", false, "Print help about command")
+	return flags
+}
+
+// ParseCommandLine parses command line arguments (without program name) and
+// returns global options, command and its arguments. Global options and
+// release shift come before command name. After command name, only options
+// declared in Command.Flags are parsed, and a single --help prints help about
+// command. Other arguments, such as entry text, are passed as is. Argument
+// '--' ends options.
+func ParseCommandLine(args []string) (*Options, *Command, []string, error) {
+	options := &Options{}
+	flags := newFlagSet(options)
+	var command *Command
+	var positional []string
+	shift := ""
+	raw := false
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		name, value, hasValue := splitOption(arg)
+		switch {
+		case command == nil && (raw || !strings.HasPrefix(arg, "-") || arg == "-") &&
+			!(arg == "next" && !raw):
+			command = CommandMapping[arg]
+			if command == nil {
+				return nil, nil, nil, commandLineError("command '%s' unknown", arg)
+			}
+		case raw:
+			positional = append(positional, arg)
+		case arg == "--":
+			raw = true
+		case command != nil:
+			// options of command, other arguments are passed as is
+			switch {
+			case arg == "--help" && len(positional) == 0 && i == len(args)-1:
+				options.Help = true
+			case !strings.HasPrefix(arg, "--"):
+				if len(command.Flags) > 0 && strings.HasPrefix(arg, "-") && len(arg) > 1 {
+					return nil, nil, nil, commandLineError("unknown option '%s' for command %s", arg, command.Name)
+				}
+				positional = append(positional, arg)
+			case indexOf(command.Flags, name) >= 0 && !hasValue:
+				positional = append(positional, arg)
+			case indexOf(command.Flags, name+"=") >= 0:
+				if !hasValue {
+					if i+1 >= len(args) {
+						return nil, nil, nil, commandLineError("option '%s' needs a value", arg)
+					}
+					i++
+					value = args[i]
+				}
+				positional = append(positional, "--"+name+"="+value)
+			case len(command.Flags) == 0:
+				positional = append(positional, arg)
+			case flags.Lookup(name) != nil:
+				return nil, nil, nil, commandLineError("option '%s' must be given before command", arg)
+			default:
+				return nil, nil, nil, commandLineError("unknown option '%s' for command %s", arg, command.Name)
+			}
+		case regexpShift.MatchString(arg) || arg == "next":
+			if shift != "" {
+				return nil, nil, nil, commandLineError("release shift given twice ('%s' and '%s')", shift, arg)
+			}
+			shift = arg
+			if arg == "next" {
+				arg = "-1"
+			}
+			options.Release, _ = strconv.Atoi(arg[1:])
+		default:
+			option := flags.Lookup(name)
+			if option == nil {
+				return nil, nil, nil, commandLineError("unknown option '%s'", arg)
+			}
+			if boolean, ok := option.Value.(interface{ IsBoolFlag() bool }); ok && boolean.IsBoolFlag() {
+				if !hasValue {
+					value = "true"
+				}
+			} else if !hasValue {
+				if i+1 >= len(args) {
+					return nil, nil, nil, commandLineError("option '%s' needs a value", arg)
+				}
+				i++
+				value = args[i]
+			}
+			if err := flags.Set(name, value); err != nil {
+				return nil, nil, nil, commandLineError("bad value '%s' for option '%s'", value, arg)
+			}
+		}
+	}
+	if options.JSON {
+		if options.Format != "" && options.Format != "json" {
+			return nil, nil, nil, commandLineError("options --json and --format %s are incompatible", options.Format)
+		}
+		options.Format = "json"
+	}
+	if options.Release < 0 {
+		return nil, nil, nil, commandLineError("release shift must be positive")
+	}
+	if command == nil {
+		if len(args) == 0 || (options.Help && len(args) == 1) {
+			return options, CommandMapping[HelpCommand], nil, nil
+		}
+		return nil, nil, nil, commandLineError("missing command")
+	}
+	if options.Help {
+		return options, CommandMapping[HelpCommand], []string{command.Name}, nil
+	}
+	if positional == nil {
+		positional = []string{}
+	}
+	return options, command, positional, nil
+}
+
+// splitOption splits an option such as --name=value in its name and value,
+// telling if a value was given
+func splitOption(arg string) (string, string, bool) {
+	name := strings.TrimLeft(arg, "-")
+	if index := strings.Index(name, "="); index >= 0 {
+		return name[:index], name[index+1:], true
+	}
+	return name, "", false
+}
+
+// commandFlags splits command arguments in flags, which are options declared
+// in Command.Flags, and other arguments. Flags map option names with their
+// value, which is "true" for options without a value.
+func commandFlags(args []string) (map[string]string, []string) {
+	flags := make(map[string]string)
+	var others []string
+	for _, arg := range args {
+		if strings.HasPrefix(arg, "--") && len(arg) > 2 {
+			name, value := arg[2:], "true"
+			if index := strings.Index(name, "="); index >= 0 {
+				name, value = name[:index], name[index+1:]
+			}
+			flags[name] = value
+		} else {
+			others = append(others, arg)
+		}
+	}
+	return flags, others
+}
+
+// HelpMessage returns help screen generated from commands and options
+func HelpMessage() string {
+	var names []string
+	for name := range CommandMapping {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var lines [][2]string
+	for _, name := range names {
+		command := CommandMapping[name]
+		lines = append(lines, [2]string{strings.TrimSpace(name + " " + command.Usage), command.Description})
+	}
+	var builder strings.Builder
+	fmt.Fprintf(&builder, "%s\n\nUsage: %s\n\nCommands:\n\n", HelpHeader, GeneralUsage)
+	writeColumns(&builder, lines)
+	builder.WriteString("\nOptions:\n\n")
+	lines = nil
+	newFlagSet(&Options{}).VisitAll(func(option *flag.Flag) {
+		name, usage := flag.UnquoteUsage(option)
+		lines = append(lines, [2]string{strings.TrimSpace("--" + option.Name + " " + name), usage})
+	})
+	writeColumns(&builder, lines)
+	builder.WriteString("\n" + HelpFooter)
+	return builder.String()
+}
+
+// CommandHelp returns help about given command
+func CommandHelp(command *Command) string {
+	help := fmt.Sprintf("Usage: %s\n\n%s", command.UsageLine(), command.Description)
+	if command.Help != "" {
+		help += "\n\n" + command.Help
+	}
+	return help
+}
+
+// maxColumnWidth is the maximum width of first column in help screen
+const maxColumnWidth = 32
+
+// writeColumns writes lines of two columns, first column being aligned. Second
+// column goes on next line if first one is too long.
+func writeColumns(writer io.Writer, lines [][2]string) {
+	width := 0
+	for _, line := range lines {
+		if len(line[0]) > width && len(line[0]) <= maxColumnWidth {
+			width = len(line[0])
+		}
+	}
+	for _, line := range lines {
+		if len(line[0]) > width {
+			fmt.Fprintf(writer, "  %s\n  %-*s  %s\n", line[0], width, "", line[1])
+		} else {
+			fmt.Fprintf(writer, "  %-*s  %s\n", width, line[0], line[1])
+		}
+	}
+}
+
+// help prints help screen, or help about command passed as argument
+func help(context *Context, args []string) error {
+	if len(args) > 1 {
+		return usageError("too many arguments")
+	}
+	if len(args) == 1 {
+		command := CommandMapping[args[0]]
+		if command == nil {
+			return usageError("command '%s' unknown", args[0])
+		}
+		fmt.Fprintln(context.Out, CommandHelp(command))
+		return nil
+	}
+	fmt.Fprintln(context.Out, HelpMessage())
+	return nil
+}
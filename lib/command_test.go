@@ -0,0 +1,73 @@
+package lib
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestParseCommandLine(t *testing.T) {
+	var tests = []struct {
+		args    []string
+		command string
+		rest    []string
+		options Options
+	}{
+		{nil, "help", nil, Options{}},
+		{[]string{"--help"}, "help", nil, Options{Help: true}},
+		{[]string{"next", "release", "version"}, "release", []string{"version"}, Options{Release: 1}},
+		{[]string{"-2", "release"}, "release", []string{}, Options{Release: 2}},
+		{[]string{"--file", "foo.yml", "--quiet", "release", "date"}, "release", []string{"date"},
+			Options{File: "foo.yml", Quiet: true}},
+		{[]string{"--format=json", "--release", "3", "check"}, "check", []string{},
+			Options{Format: "json", Release: 3}},
+		{[]string{"add", "fixed", "Handle", "-1", "return", "code"}, "add",
+			[]string{"fixed", "Handle", "-1", "return", "code"}, Options{}},
+		{[]string{"add", "added", "Support", "--quiet", "option"}, "add",
+			[]string{"added", "Support", "--quiet", "option"}, Options{}},
+		{[]string{"add", "fixed", "next", "--help"}, "add", []string{"fixed", "next", "--help"}, Options{}},
+		{[]string{"add", "fixed", "--", "--file", "next"}, "add", []string{"fixed", "--file", "next"},
+			Options{}},
+		{[]string{"release", "--help"}, "help", []string{"release"}, Options{Help: true}},
+		{[]string{"fmt", "--check", "--diff"}, "fmt", []string{"--check", "--diff"}, Options{}},
+		{[]string{"generate", "--from", "v1.0.0", "--to=HEAD", "--merge"}, "generate",
+			[]string{"--from=v1.0.0", "--to=HEAD", "--merge"}, Options{}},
+	}
+	for _, test := range tests {
+		options, command, args, err := ParseCommandLine(test.args)
+		if err != nil {
+			t.Errorf("Parsing %v: %v", test.args, err)
+			continue
+		}
+		if command.Name != test.command || strings.Join(args, " ") != strings.Join(test.rest, " ") ||
+			*options != test.options {
+			t.Errorf("Parsing %v gave %s %v %+v", test.args, command.Name, args, *options)
+		}
+	}
+	for _, args := range [][]string{
+		{"foo"}, {"--foo", "release"}, {"release", "--file"}, {"--release", "x", "release"},
+		{"-1", "-2", "release"}, {"--check", "fmt"}, {"release", "--check"},
+		{"generate", "--from"}, {"fmt", "--check=true"}, {"-1"}, {"--quiet"},
+		{"release", "--quiet", "date"}, {"release", "-1"},
+	} {
+		_, _, _, err := ParseCommandLine(args)
+		var usage *UsageError
+		if !errors.As(err, &usage) {
+			t.Errorf("Parsing %v should give a usage error, got %v", args, err)
+		}
+	}
+}
+
+func TestHelpMessage(t *testing.T) {
+	help := HelpMessage()
+	for name, command := range CommandMapping {
+		if !strings.Contains(help, command.Description) {
+			t.Errorf("Help screen should describe command %s", name)
+		}
+	}
+	for _, option := range []string{"--file", "--format", "--release", "--quiet"} {
+		if !strings.Contains(help, option) {
+			t.Errorf("Help screen should describe option %s", option)
+		}
+	}
+}
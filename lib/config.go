@@ -0,0 +1,180 @@
+package lib
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"text/template"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ConfigFile is the name of project configuration file
+const ConfigFile = ".changelog.yml"
+
+// Config is the project configuration
+type Config struct {
+	// File is the path of changelog file, relative to configuration file
+	File string
+	// Version is the configuration of version scheme
+	Version VersionConfig
+	// Sections are the allowed release sections, in order
+	Sections []string
+	// Titles are the titles of sections by name, such as Added
+	Titles map[string]string
+	// Format is the default format to transform changelog to
+	Format string
+	// Templates are template files overriding builtin ones, by name
+	Templates map[string]string
+	// Date is the format of release dates, such as YYYY-MM-DD
+	Date string
+	// Repository is the configuration of source repository
+	Repository RepositoryConfig
+	// dir is the directory of configuration file
+	dir string
+}
+
+// VersionConfig is the configuration of version scheme
+type VersionConfig struct {
+	// Scheme is loose (default), semver, calver or regex
+	Scheme string
+	// Pattern is the regexp for regex scheme
+	Pattern string
+	// Prefix is the prefix of versions, such as v
+	Prefix string
+}
+
+// RepositoryConfig is the configuration of source repository
+type RepositoryConfig struct {
+	// Tag is the prefix of release tags, such as v
+	Tag string
+	// Compare is the URL comparing two tags, with {from} and {to} placeholders
+	Compare string
+	// Release is the URL of a release tag, with {tag} placeholder
+	Release string
+	// Tolerance is the number of days a tag date may differ from release date
+	Tolerance int
+}
+
+// LoadConfig loads configuration file in given directory. Returns default
+// configuration if there is no such file.
+func LoadConfig(dir string) (*Config, error) {
+	config := &Config{dir: dir}
+	file := filepath.Join(dir, ConfigFile)
+	source, err := ioutil.ReadFile(filepath.Clean(file))
+	if os.IsNotExist(err) {
+		return config, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("reading configuration file '%s'", file)
+	}
+	decoder := yaml.NewDecoder(bytes.NewReader(source))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(config); err != nil && err != io.EOF {
+		return nil, fmt.Errorf("parsing configuration file '%s': %v", file, err)
+	}
+	if err := config.check(); err != nil {
+		return nil, fmt.Errorf("in configuration file '%s': %v", file, err)
+	}
+	return config, nil
+}
+
+// regexpSectionName matches names of sections, such as added or breaking-changes
+var regexpSectionName = regexp.MustCompile(`^[a-z][a-z0-9-]*$`)
+
+// check validates configuration
+func (c *Config) check() error {
+	if _, err := NewVersionScheme(c.Version.Scheme, c.Version.Pattern, c.Version.Prefix); err != nil {
+		return err
+	}
+	seen := make(map[string]bool)
+	for _, section := range c.Sections {
+		if !regexpSectionName.MatchString(section) || fieldIndex(section) >= 0 {
+			return fmt.Errorf("bad section name '%s' (must be lower case letters, digits and dashes)", section)
+		}
+		if seen[section] {
+			return fmt.Errorf("duplicate section '%s'", section)
+		}
+		seen[section] = true
+	}
+	sections := c.Sections
+	if len(sections) == 0 {
+		sections = DefaultSections
+	}
+	for name := range c.Titles {
+		if indexOf(sections, name) < 0 {
+			return fmt.Errorf("title of unknown section '%s'", name)
+		}
+	}
+	if c.Format != "" && !isFormat(c.Format) {
+		return fmt.Errorf("unknown format '%s' (must be one of %s)", c.Format,
+			strings.Join(Formats, ", "))
+	}
+	for name, file := range c.Templates {
+		if _, ok := Templates[name]; !ok {
+			return fmt.Errorf("unknown template '%s' (must be one of %s)", name,
+				strings.Join(templateNames(), ", "))
+		}
+		source, err := ioutil.ReadFile(filepath.Clean(filepath.Join(c.dir, file)))
+		if err != nil {
+			return fmt.Errorf("reading template '%s' file '%s'", name, file)
+		}
+		if _, err := template.New(name).Funcs(templateFuncs).Parse(string(source)); err != nil {
+			return fmt.Errorf("parsing template '%s': %v", name, err)
+		}
+	}
+	if c.Date != "" {
+		layout := dateLayout(c.Date)
+		sample := time.Date(2001, 12, 31, 0, 0, 0, 0, time.UTC)
+		if parsed, err := time.Parse(layout, sample.Format(layout)); err != nil || !parsed.Equal(sample) {
+			return fmt.Errorf("date format '%s' must include YYYY, MM and DD", c.Date)
+		}
+	}
+	if c.Repository.Compare != "" && (!strings.Contains(c.Repository.Compare, "{from}") ||
+		!strings.Contains(c.Repository.Compare, "{to}")) {
+		return fmt.Errorf("compare URL '%s' must include {from} and {to}", c.Repository.Compare)
+	}
+	if c.Repository.Release != "" && !strings.Contains(c.Repository.Release, "{tag}") {
+		return fmt.Errorf("release URL '%s' must include {tag}", c.Repository.Release)
+	}
+	if c.Repository.Tolerance < 0 {
+		return fmt.Errorf("tag date tolerance must be positive")
+	}
+	return nil
+}
+
+// dateLayout converts date format such as YYYY-MM-DD to a Go time layout
+func dateLayout(format string) string {
+	return strings.NewReplacer("YYYY", "2006", "MM", "01", "DD", "02").Replace(format)
+}
+
+// Apply applies configuration
+func (c *Config) Apply() error {
+	if err := c.check(); err != nil {
+		return err
+	}
+	Scheme, _ = NewVersionScheme(c.Version.Scheme, c.Version.Pattern, c.Version.Prefix)
+	if len(c.Sections) > 0 {
+		Sections = c.Sections
+	}
+	if c.Titles != nil {
+		SectionTitles = c.Titles
+	}
+	if c.Format != "" {
+		DefaultFormat = c.Format
+	}
+	for name, file := range c.Templates {
+		source, _ := ioutil.ReadFile(filepath.Clean(filepath.Join(c.dir, file)))
+		Templates[name] = string(source)
+	}
+	if c.Date != "" {
+		DateFormat = dateLayout(c.Date)
+	}
+	Repository = c.Repository
+	return nil
+}
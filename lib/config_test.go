@@ -0,0 +1,88 @@
+package lib
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeConfig(t *testing.T, config string) string {
+	t.Helper()
+	dir := t.TempDir()
+	if err := ioutil.WriteFile(filepath.Join(dir, ConfigFile), []byte(config), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+func TestLoadConfig(t *testing.T) {
+	dir := writeConfig(t, `file: docs/CHANGELOG.yml
+version:
+  scheme: semver
+  prefix: v
+sections: [fixed, added, performance]
+titles:
+  performance: Performance improvements
+format: markdown
+templates:
+  markdown: changelog.tmpl
+date: DD/MM/YYYY
+`)
+	if err := ioutil.WriteFile(filepath.Join(dir, "changelog.tmpl"), []byte("{{ len .Changelog }}"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	config, err := LoadConfig(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.File != "docs/CHANGELOG.yml" || config.Version.Prefix != "v" {
+		t.Errorf("Bad configuration: %+v", config)
+	}
+	scheme, sections, titles, format, dateFormat := Scheme, Sections, SectionTitles, DefaultFormat, DateFormat
+	markdown := Templates["markdown"]
+	defer func() {
+		Scheme, Sections, SectionTitles, DefaultFormat, DateFormat = scheme, sections, titles, format, dateFormat
+		Templates["markdown"] = markdown
+	}()
+	if err := config.Apply(); err != nil {
+		t.Fatal(err)
+	}
+	if Scheme.Check("v1.2.3") != nil || strings.Join(Sections, ",") != "fixed,added,performance" ||
+		SectionTitle("performance") != "Performance improvements" || SectionTitle("fixed") != "Fixed" ||
+		DefaultFormat != "markdown" || DateFormat != "02/01/2006" ||
+		Templates["markdown"] != "{{ len .Changelog }}" {
+		t.Errorf("Configuration was not applied")
+	}
+	errors := checkRelease(0, Release{Version: "v1.2.3", Date: "2015-03-30"})
+	if len(errors) != 1 || errors[0].Message != "Release date '2015-03-30' doesn't match date format 02/01/2006" {
+		t.Errorf("Bad errors with date format: %v", errors)
+	}
+}
+
+func TestLoadConfigErrors(t *testing.T) {
+	var configs = map[string]string{
+		"foo: bar":                                "field foo not found",
+		"version:\n  scheme: foo":                 "unknown version scheme 'foo'",
+		"sections: [added, Performance]":          "bad section name 'Performance'",
+		"sections: [added, summary]":              "bad section name 'summary'",
+		"titles:\n  performance: Performance":     "title of unknown section 'performance'",
+		"sections: [added, added]":                "duplicate section 'added'",
+		"format: pdf":                             "unknown format 'pdf'",
+		"templates:\n  foo: bar.tmpl":             "unknown template 'foo'",
+		"templates:\n  html: none.tmpl":           "reading template 'html' file 'none.tmpl'",
+		"date: YYYY-MM":                           "date format 'YYYY-MM' must include YYYY, MM and DD",
+		"repository:\n  compare: http://x/{from}": "compare URL 'http://x/{from}' must include {from} and {to}",
+		"repository:\n  release: http://x/":       "release URL 'http://x/' must include {tag}",
+	}
+	for config, message := range configs {
+		_, err := LoadConfig(writeConfig(t, config))
+		if err == nil || !strings.Contains(err.Error(), message) {
+			t.Errorf("Loading configuration %q should fail with %q, got %v", config, message, err)
+		}
+	}
+	config, err := LoadConfig(t.TempDir())
+	if err != nil || config.File != "" {
+		t.Errorf("Missing configuration file should give default configuration")
+	}
+}
@@ -0,0 +1,103 @@
+package lib
+
+import (
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// cutRelease turns top release into a dated release: pre-release suffix is
+// stripped from version and date is set to today. Unreleased release gets
+// version passed as argument, or suggested by its changes. With tag flag,
+// changelog is committed and an annotated tag is created with release
+// description. With dry-run flag, changes are printed as a diff.
+func cutRelease(context *Context, flags map[string]string, args []string) error {
+	if len(context.Changelog) == 0 {
+		return fmt.Errorf("no release to cut")
+	}
+	released := context.Changelog[0]
+	if !released.Unreleased() && !Scheme.Prerelease(released.Version) {
+		return fmt.Errorf("top release %s is not a pre-release or unreleased", released.Version)
+	}
+	if len(args) > 1 || (len(args) == 1 && !released.Unreleased()) {
+		return usageError("version can only be passed to cut an unreleased release")
+	}
+	if len(args) == 1 {
+		if err := Scheme.Check(args[0]); err != nil {
+			return usageError("bad release version: %v", err)
+		}
+		released.Version = args[0]
+	} else if released.Unreleased() && len(context.Changelog) == 1 {
+		// first release
+		version, err := nextVersion(nil, nil)
+		if err != nil {
+			return fmt.Errorf("computing version: %v", err)
+		}
+		released.Version = version
+	} else if released.Unreleased() {
+		version, _, err := SuggestVersion(context.Changelog)
+		if err != nil {
+			return fmt.Errorf("suggesting version: %v", err)
+		}
+		released.Version = version
+	} else {
+		released.Version = Scheme.Final(released.Version)
+	}
+	released.Date = now().Local().Format(DateFormat)
+	document, err := context.Document()
+	if err != nil {
+		return err
+	}
+	if err := document.SetField(context.Shift, "version", released.Version); err != nil {
+		return fmt.Errorf("setting version: %v", err)
+	}
+	if err := document.SetField(context.Shift, "date", released.Date); err != nil {
+		return fmt.Errorf("setting date: %v", err)
+	}
+	changelog, err := document.Changelog()
+	if err != nil {
+		return err
+	}
+	if err := checkChangelog(changelog, document.Bytes(), 0); err != nil {
+		return fmt.Errorf("checking released changelog: %v", err)
+	}
+	dir := context.gitDir()
+	tag := Repository.TagName(released.Version)
+	if flags["tag"] != "" {
+		if context.File == "" {
+			return fmt.Errorf("can't tag a piped changelog")
+		}
+		if gitRefExists(dir, "refs/tags/"+tag) {
+			return fmt.Errorf("tag %s already exists", tag)
+		}
+	}
+	if flags["dry-run"] != "" {
+		fmt.Fprint(context.Out, Diff(context.File, context.Source, document.Bytes()))
+		if flags["tag"] != "" {
+			fmt.Fprintf(context.Out, "Would commit changelog and create tag %s\n", tag)
+		}
+		return nil
+	}
+	if err := context.Write(document); err != nil {
+		return err
+	}
+	if flags["tag"] != "" {
+		var message bytes.Buffer
+		if err := releaseToMarkdown(&message, released, flags["template"]); err != nil {
+			return fmt.Errorf("generating tag message: %v", err)
+		}
+		text := strings.TrimSpace(message.String())
+		if text == "" {
+			text = "Release " + released.Version
+		}
+		if _, err := git(dir, "commit", "--quiet", "-m", "Release "+released.Version, "--", filepath.Base(context.File)); err != nil {
+			return fmt.Errorf("committing changelog: %v", err)
+		}
+		if _, err := git(dir, "tag", "--annotate", "--cleanup=verbatim", "-m", text, tag); err != nil {
+			return fmt.Errorf("creating tag: %v", err)
+		}
+	}
+	fmt.Fprintln(context.Out, released.Version)
+	return nil
+}
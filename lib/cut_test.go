@@ -0,0 +1,114 @@
+package lib
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCutRelease(t *testing.T) {
+	now = func() time.Time { return time.Date(2024, 3, 1, 12, 0, 0, 0, time.Local) }
+	repository := Repository
+	defer func() { now, Repository = time.Now, repository }()
+	Repository = RepositoryConfig{Tag: "v"}
+	dir := initRepository(t, "", "initial commit")
+	for _, config := range [][]string{{"user.name", "test"}, {"user.email", "test@example.com"}} {
+		if _, err := git(dir, "config", config[0], config[1]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	source := "- version: 1.1.0-SNAPSHOT # next\n  date:    2024-04-01\n  added:\n  - Feature\n\n" +
+		"- version: 1.0.0\n  date:    2024-01-01\n"
+	file := filepath.Join(dir, "CHANGELOG.yml")
+	if err := WriteChangelog(file, []byte(source)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := git(dir, "add", "CHANGELOG.yml"); err != nil {
+		t.Fatal(err)
+	}
+	changelog, _ := ParseChangelog([]byte(source))
+	var out strings.Builder
+	context := &Context{Options: &Options{}, Out: &out, File: file, Source: []byte(source), Changelog: changelog}
+	if err := release(context, []string{"cut", "--dry-run", "--tag"}); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), "+- version: 1.1.0 # next\n+  date:    2024-03-01\n") ||
+		!strings.Contains(out.String(), "Would commit changelog and create tag v1.1.0") {
+		t.Errorf("Bad dry run output:\n%s", out.String())
+	}
+	if written, _ := ioutil.ReadFile(file); string(written) != source {
+		t.Errorf("Dry run should not write changelog")
+	}
+	out.Reset()
+	if err := release(context, []string{"cut", "--tag"}); err != nil {
+		t.Fatal(err)
+	}
+	written, _ := ioutil.ReadFile(file)
+	if !strings.HasPrefix(string(written), "- version: 1.1.0 # next\n  date:    2024-03-01\n") {
+		t.Errorf("Bad released changelog:\n%s", written)
+	}
+	message, err := git(dir, "tag", "--list", "--format=%(contents)", "v1.1.0")
+	if err != nil || message != "# Added\n\n- Feature\n" {
+		t.Errorf("Bad tag message %q (%v)", message, err)
+	}
+	if status, _ := git(dir, "status", "--porcelain"); status != "" {
+		t.Errorf("Changelog should be committed: %s", status)
+	}
+	if err := release(context, []string{"version", "--tag"}); err == nil {
+		t.Errorf("Option --tag should only be accepted for cut")
+	}
+}
+
+func TestCutUnreleased(t *testing.T) {
+	now = func() time.Time { return time.Date(2024, 3, 1, 12, 0, 0, 0, time.Local) }
+	defer func() { now = time.Now }()
+	source := "- version: unreleased\n  added:\n  - Feature\n\n- version: 1.0.0\n  date:    2024-01-01\n"
+	file := filepath.Join(t.TempDir(), "CHANGELOG.yml")
+	changelog, _ := ParseChangelog([]byte(source))
+	var out strings.Builder
+	context := &Context{Options: &Options{}, Out: &out, File: file, Source: []byte(source), Changelog: changelog}
+	if err := release(context, []string{"cut", "--dry-run"}); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), "-- version: unreleased\n+- version: 1.1.0\n+  date:    2024-03-01\n") {
+		t.Errorf("Unreleased release should get suggested version:\n%s", out.String())
+	}
+	out.Reset()
+	if err := release(context, []string{"cut", "2.0.0"}); err != nil {
+		t.Fatal(err)
+	}
+	written, _ := ioutil.ReadFile(file)
+	if !strings.HasPrefix(string(written), "- version: 2.0.0\n  date:    2024-03-01\n  added:\n") {
+		t.Errorf("Unreleased release should get given version:\n%s", written)
+	}
+}
+
+func TestCutFinalRelease(t *testing.T) {
+	source := "- version: 1.0.0\n  date:    2015-03-30\n"
+	changelog, _ := ParseChangelog([]byte(source))
+	var out strings.Builder
+	context := &Context{Options: &Options{}, Out: &out, File: filepath.Join(t.TempDir(), "CHANGELOG.yml"),
+		Source: []byte(source), Changelog: changelog}
+	err := release(context, []string{"cut"})
+	if err == nil || err.Error() != "top release 1.0.0 is not a pre-release or unreleased" {
+		t.Errorf("Cutting a final release should fail, got %v", err)
+	}
+}
+
+func TestCutUnreleasedBumpError(t *testing.T) {
+	scheme := Scheme
+	defer func() { Scheme = scheme }()
+	Scheme = RegexScheme{Regexp: regexp.MustCompile(`^r\d+\.\d+$`)}
+	source := "- version: unreleased\n  fixed:\n  - Bug\n\n- version: r1.2\n  date:    2024-01-01\n"
+	changelog, _ := ParseChangelog([]byte(source))
+	var out strings.Builder
+	context := &Context{Options: &Options{}, Out: &out, File: filepath.Join(t.TempDir(), "CHANGELOG.yml"),
+		Source: []byte(source), Changelog: changelog}
+	err := release(context, []string{"cut", "--dry-run"})
+	if err == nil || err.Error() != "suggesting version: bumping version r1.2: version 'r1.2' has no patch number" {
+		t.Errorf("Bump failure should be reported, got %v", err)
+	}
+}
@@ -0,0 +1,127 @@
+package lib
+
+import (
+	"fmt"
+	"strings"
+)
+
+// diffContext is the number of context lines in unified diffs
+const diffContext = 3
+
+// Diff returns unified diff between old and new texts, empty if they are
+// the same. Name is the name of the file in diff header.
+func Diff(name string, old, new []byte) string {
+	if string(old) == string(new) {
+		return ""
+	}
+	a := splitLines(string(old))
+	b := splitLines(string(new))
+	// lcs[i][j] is the length of longest common subsequence of a[i:] and b[j:]
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+	// edits are lines prefixed with ' ', '-' or '+'
+	var edits []string
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			edits = append(edits, " "+a[i])
+			i++
+			j++
+		case j >= len(b) || (i < len(a) && lcs[i+1][j] >= lcs[i][j+1]):
+			edits = append(edits, "-"+a[i])
+			i++
+		default:
+			edits = append(edits, "+"+b[j])
+			j++
+		}
+	}
+	var builder strings.Builder
+	fmt.Fprintf(&builder, "--- %s\n+++ %s\n", name, name)
+	// group edits in hunks with context lines around changes
+	for start := 0; start < len(edits); {
+		if edits[start][0] == ' ' {
+			start++
+			continue
+		}
+		first := start - diffContext
+		if first < 0 {
+			first = 0
+		}
+		end := start
+		for end < len(edits) {
+			if edits[end][0] != ' ' {
+				end++
+				continue
+			}
+			next := end
+			for next < len(edits) && edits[next][0] == ' ' {
+				next++
+			}
+			if next == len(edits) || next-end > 2*diffContext {
+				break
+			}
+			end = next
+		}
+		last := end + diffContext
+		if last > len(edits) {
+			last = len(edits)
+		}
+		oldStart, newStart := 1, 1
+		for _, edit := range edits[:first] {
+			if edit[0] != '+' {
+				oldStart++
+			}
+			if edit[0] != '-' {
+				newStart++
+			}
+		}
+		oldCount, newCount := 0, 0
+		for _, edit := range edits[first:last] {
+			if edit[0] != '+' {
+				oldCount++
+			}
+			if edit[0] != '-' {
+				newCount++
+			}
+		}
+		fmt.Fprintf(&builder, "@@ -%s +%s @@\n", hunkRange(oldStart, oldCount), hunkRange(newStart, newCount))
+		for _, edit := range edits[first:last] {
+			builder.WriteString(edit + "\n")
+		}
+		start = last
+	}
+	return builder.String()
+}
+
+// hunkRange formats a range of lines in hunk header
+func hunkRange(start, count int) string {
+	if count == 0 {
+		start--
+	}
+	if count == 1 {
+		return fmt.Sprintf("%d", start)
+	}
+	return fmt.Sprintf("%d,%d", start, count)
+}
+
+// splitLines splits text in lines, without trailing newline
+func splitLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+}
@@ -0,0 +1,375 @@
+package lib
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Document is a changelog file that can be edited and written back. Edits
+// are applied to source lines so that comments, blank lines and alignment
+// outside edited parts are left untouched.
+type Document struct {
+	lines   []string
+	newline bool
+	root    *yaml.Node
+}
+
+// ParseDocument parses changelog source into a document
+func ParseDocument(source []byte) (*Document, error) {
+	text := string(source)
+	document := &Document{newline: strings.HasSuffix(text, "\n")}
+	text = strings.TrimSuffix(text, "\n")
+	if text != "" {
+		document.lines = strings.Split(text, "\n")
+	}
+	if err := document.parse(); err != nil {
+		return nil, err
+	}
+	return document, nil
+}
+
+// parse parses lines to refresh the node tree after an edit
+func (d *Document) parse() error {
+	var root yaml.Node
+	if err := yaml.Unmarshal(d.Bytes(), &root); err != nil {
+		return fmt.Errorf("parsing changelog: %v", err)
+	}
+	d.root = &root
+	if d.sequence() == nil && len(root.Content) > 0 && root.Content[0].Tag != "!!null" {
+		return fmt.Errorf("parsing changelog: changelog must be a list of releases")
+	}
+	return nil
+}
+
+// Bytes returns document source
+func (d *Document) Bytes() []byte {
+	text := strings.Join(d.lines, "\n")
+	if d.newline {
+		text += "\n"
+	}
+	return []byte(text)
+}
+
+// Changelog decodes the document into a changelog
+func (d *Document) Changelog() (Changelog, error) {
+	return ParseChangelog(d.Bytes())
+}
+
+// Len returns the number of releases in document
+func (d *Document) Len() int {
+	if sequence := d.sequence(); sequence != nil {
+		return len(sequence.Content)
+	}
+	return 0
+}
+
+// sequence returns the sequence node of releases, nil if document is empty
+func (d *Document) sequence() *yaml.Node {
+	if d.root == nil || len(d.root.Content) == 0 {
+		return nil
+	}
+	node := d.root.Content[0]
+	if node.Kind != yaml.SequenceNode {
+		return nil
+	}
+	return node
+}
+
+// release returns mapping node for release at given index
+func (d *Document) release(index int) (*yaml.Node, error) {
+	if index < 0 || index >= d.Len() {
+		return nil, fmt.Errorf("no release at index %d", index)
+	}
+	node := d.sequence().Content[index]
+	if node.Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("release at index %d is not a map", index)
+	}
+	if node.Style&yaml.FlowStyle != 0 {
+		return nil, fmt.Errorf("release at index %d is in flow style", index)
+	}
+	return node, nil
+}
+
+// releaseEnd returns the last line (1-based) of release at given index
+func (d *Document) releaseEnd(index int) int {
+	end := len(d.lines)
+	if index+1 < d.Len() {
+		end = d.sequence().Content[index+1].Line - 1
+	}
+	return d.trimEnd(end)
+}
+
+// trimEnd skips blank and comment lines backwards from given line
+func (d *Document) trimEnd(line int) int {
+	for line > 0 {
+		text := strings.TrimSpace(d.lines[line-1])
+		if text != "" && !strings.HasPrefix(text, "#") {
+			break
+		}
+		line--
+	}
+	return line
+}
+
+// insert inserts lines after given line (1-based, 0 to insert at top)
+func (d *Document) insert(line int, lines ...string) error {
+	if line == len(d.lines) {
+		d.newline = true
+	}
+	updated := make([]string, 0, len(d.lines)+len(lines))
+	updated = append(updated, d.lines[:line]...)
+	updated = append(updated, lines...)
+	updated = append(updated, d.lines[line:]...)
+	d.lines = updated
+	return d.parse()
+}
+
+// InsertRelease inserts a release at the top of the document. The leading
+// comment block of the file stays on top.
+func (d *Document) InsertRelease(release Release) error {
+	lines := formatRelease(release)
+	if sequence := d.sequence(); sequence != nil && sequence.Style&yaml.FlowStyle != 0 {
+		// empty list such as [] is replaced with the release
+		text := strings.TrimSpace(d.lines[sequence.Line-1])
+		if len(sequence.Content) > 0 || text != "[]" {
+			return fmt.Errorf("list of releases is in flow style")
+		}
+		d.lines = append(d.lines[:sequence.Line-1], d.lines[sequence.Line:]...)
+		return d.insert(sequence.Line-1, lines...)
+	}
+	if d.Len() == 0 {
+		return d.insert(len(d.lines), lines...)
+	}
+	// indent release as the list of releases, at column of its dashes
+	indent := strings.Repeat(" ", d.sequence().Column-1)
+	for i := range lines {
+		lines[i] = indent + lines[i]
+	}
+	line := d.sequence().Content[0].Line - 1
+	// keep comments attached to first release with it, unless they start the file
+	top := line
+	for top > 0 && strings.HasPrefix(strings.TrimSpace(d.lines[top-1]), "#") {
+		top--
+	}
+	if top > 0 {
+		line = top
+	}
+	return d.insert(line, append(lines, "")...)
+}
+
+// AddEntry appends an entry to a section of release at given index. The
+// section is created if it doesn't exist.
+func (d *Document) AddEntry(index int, section, entry string) error {
+	node, err := d.release(index)
+	if err != nil {
+		return err
+	}
+	keys := node.Content
+	for i := 0; i < len(keys); i += 2 {
+		if keys[i].Value != section {
+			continue
+		}
+		value := keys[i+1]
+		if value.Kind == yaml.ScalarNode && value.Tag == "!!null" {
+			// empty section, such as "added:" without entries
+			if !strings.HasSuffix(strings.TrimSpace(d.lines[keys[i].Line-1]), ":") {
+				return fmt.Errorf("section %s of release at index %d is not a block list", section, index)
+			}
+			indent := strings.Repeat(" ", keys[i].Column-1)
+			return d.insert(keys[i].Line, indent+"- "+formatScalar(entry))
+		}
+		if value.Kind != yaml.SequenceNode || value.Style&yaml.FlowStyle != 0 {
+			return fmt.Errorf("section %s of release at index %d is not a block list", section, index)
+		}
+		end := d.releaseEnd(index)
+		if i+2 < len(keys) {
+			end = d.trimEnd(keys[i+2].Line - 1)
+		}
+		last := value.Content[len(value.Content)-1]
+		prefix := d.lines[last.Line-1][:last.Column-1]
+		dash := strings.LastIndex(prefix, "-")
+		if dash < 0 {
+			return fmt.Errorf("section %s of release at index %d has unexpected layout", section, index)
+		}
+		return d.insert(end, prefix[:dash]+"- "+formatScalar(entry))
+	}
+	// section not found: insert it before next section in configured order
+	indent := strings.Repeat(" ", node.Column-1)
+	lines := []string{indent + section + ":", indent + "- " + formatScalar(entry)}
+	position := sectionIndex(section)
+	for i := 0; i < len(keys); i += 2 {
+		if other := sectionIndex(keys[i].Value); other > position {
+			return d.insert(keys[i].Line-1, lines...)
+		}
+	}
+	return d.insert(d.releaseEnd(index), lines...)
+}
+
+// HeaderFields are the scalar fields of a release, in canonical order
+var HeaderFields = []string{"version", "date", "summary"}
+
+// SetField sets value of a scalar field (version, date or summary) of release
+// at given index. Alignment and trailing comment of the field are kept. A
+// missing field is inserted after the fields that precede it.
+func (d *Document) SetField(index int, field, value string) error {
+	node, err := d.release(index)
+	if err != nil {
+		return err
+	}
+	keys := node.Content
+	for i := 0; i < len(keys); i += 2 {
+		if keys[i].Value != field {
+			continue
+		}
+		key, old := keys[i], keys[i+1]
+		if old.Kind != yaml.ScalarNode || old.Style&(yaml.LiteralStyle|yaml.FoldedStyle) != 0 {
+			return fmt.Errorf("field %s of release at index %d is not a single line value", field, index)
+		}
+		text := d.lines[key.Line-1]
+		var line string
+		if old.Tag == "!!null" && old.Value == "" && old.Line == key.Line {
+			// empty value such as "date:" is a null node just after colon:
+			// value is aligned as for a new field
+			line = text[:key.Column-1] + fmt.Sprintf("%-9s", field+":") + formatScalar(value)
+		} else if old.Line == key.Line && old.Column > key.Column {
+			line = text[:old.Column-1] + formatScalar(value)
+		} else {
+			colon := strings.Index(text[key.Column-1:], ":") + key.Column
+			line = text[:colon] + " " + formatScalar(value)
+		}
+		if old.LineComment != "" {
+			line += " " + old.LineComment
+		}
+		d.lines[key.Line-1] = line
+		return d.parse()
+	}
+	position := fieldIndex(field)
+	if position < 0 {
+		return fmt.Errorf("unknown field %s", field)
+	}
+	indent := strings.Repeat(" ", node.Column-1)
+	line := indent + fmt.Sprintf("%-9s", field+":") + formatScalar(value)
+	// insert after the last line of preceding header field
+	after := node.Line - 1
+	for i := 0; i < len(keys); i += 2 {
+		if other := fieldIndex(keys[i].Value); other >= 0 && other < position {
+			after = keys[i].Line
+		}
+	}
+	if after < node.Line {
+		// field goes first and must take the place of the dash
+		first := d.lines[node.Line-1]
+		d.lines[node.Line-1] = indent + first[node.Column-1:]
+		line = first[:node.Column-1] + line[node.Column-1:]
+	}
+	return d.insert(after, line)
+}
+
+// RemoveField removes a scalar field (such as date or summary) of release at
+// given index
+func (d *Document) RemoveField(index int, field string) error {
+	node, err := d.release(index)
+	if err != nil {
+		return err
+	}
+	keys := node.Content
+	for i := 0; i < len(keys); i += 2 {
+		if keys[i].Value != field {
+			continue
+		}
+		if keys[i+1].Kind != yaml.ScalarNode || keys[i+1].Line != keys[i].Line {
+			return fmt.Errorf("field %s of release at index %d is not a single line value", field, index)
+		}
+		if len(keys) == 2 {
+			return fmt.Errorf("can't remove last field of release at index %d", index)
+		}
+		line := keys[i].Line
+		if i == 0 {
+			// the dash of the release is on this line: move it to next field
+			next := keys[2]
+			text := d.lines[next.Line-1]
+			d.lines[next.Line-1] = d.lines[line-1][:node.Column-1] + text[next.Column-1:]
+		}
+		d.lines = append(d.lines[:line-1], d.lines[line:]...)
+		return d.parse()
+	}
+	return nil
+}
+
+// fieldIndex returns position of header field in canonical order, -1 if unknown
+func fieldIndex(name string) int {
+	return indexOf(HeaderFields, name)
+}
+
+// sectionIndex returns position of section in configured order, -1 if not
+// allowed
+func sectionIndex(name string) int {
+	return indexOf(Sections, name)
+}
+
+// indexOf returns index of value in list, -1 if not found
+func indexOf(list []string, value string) int {
+	for i, element := range list {
+		if element == value {
+			return i
+		}
+	}
+	return -1
+}
+
+// FormatChangelog renders changelog as normalized YAML: fields in canonical
+// order, aligned header fields, two spaces indentation, quoting only where
+// necessary and a blank line between releases
+func FormatChangelog(changelog Changelog) []byte {
+	var lines []string
+	for i, release := range changelog {
+		if i > 0 {
+			lines = append(lines, "")
+		}
+		lines = append(lines, formatRelease(release)...)
+	}
+	if len(lines) == 0 {
+		return []byte("[]\n")
+	}
+	return []byte(strings.Join(lines, "\n") + "\n")
+}
+
+// formatRelease renders release as YAML lines
+func formatRelease(release Release) []string {
+	lines := []string{"- version: " + formatScalar(release.Version)}
+	if release.Date != "" {
+		lines = append(lines, "  date:    "+formatScalar(release.Date))
+	}
+	if release.Summary != "" {
+		lines = append(lines, "  summary: "+formatScalar(release.Summary))
+	}
+	for _, section := range release.Sections {
+		for i, entry := range section.Entries {
+			if i == 0 {
+				lines = append(lines, "  "+section.Name+":")
+			}
+			lines = append(lines, "  - "+formatScalar(entry))
+		}
+	}
+	return lines
+}
+
+// formatScalar renders a string as a YAML scalar, quoted only if it would be
+// read as another string or another type, except for ISO dates that are left
+// plain as usual in changelogs
+func formatScalar(value string) string {
+	if strings.Contains(value, "\n") {
+		return strconv.Quote(value)
+	}
+	if RegexpDate.MatchString(value) {
+		return value
+	}
+	out, err := yaml.Marshal(value)
+	if err != nil {
+		return value
+	}
+	return strings.TrimSuffix(string(out), "\n")
+}
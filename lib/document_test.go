@@ -0,0 +1,263 @@
+package lib
+
+import (
+	"flag"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "update golden files")
+
+// checkGolden compares document with golden file in test/golden directory
+func checkGolden(t *testing.T, document *Document, name string) {
+	t.Helper()
+	golden := filepath.Join("..", "test", "golden", name)
+	if *update {
+		if err := ioutil.WriteFile(golden, document.Bytes(), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	expected, err := ioutil.ReadFile(golden)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(document.Bytes()) != string(expected) {
+		t.Errorf("Document doesn't match golden file %s:\n%s", golden, document.Bytes())
+	}
+}
+
+// loadDocument loads test/CHANGELOG.yml document
+func loadDocument(t *testing.T) *Document {
+	t.Helper()
+	source, err := ioutil.ReadFile("../test/CHANGELOG.yml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	document, err := ParseDocument(source)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return document
+}
+
+func TestDocumentRoundTrip(t *testing.T) {
+	var sources = []string{
+		"",
+		"# Only a comment\n",
+		"- version: 1.0.0\n  date:    2015-03-30\n",
+		"- version: 1.0.0\r\n  date: 2015-03-30 # comment\n\n\n",
+		"- version: 1.0.0\n  date: 2015-03-30",
+	}
+	for _, source := range sources {
+		document, err := ParseDocument([]byte(source))
+		if err != nil {
+			t.Fatalf("Parsing %q: %v", source, err)
+		}
+		if string(document.Bytes()) != source {
+			t.Errorf("Round trip of %q gave %q", source, document.Bytes())
+		}
+	}
+	checkGolden(t, loadDocument(t), "round-trip.yml")
+}
+
+func TestDocumentGolden(t *testing.T) {
+	document := loadDocument(t)
+	if err := document.InsertRelease(Release{Version: "1.1.0", Date: "2015-04-01",
+		Summary: "Third release: with colon", Sections: []Section{{"added", []string{"Added."}}}}); err != nil {
+		t.Fatal(err)
+	}
+	checkGolden(t, document, "insert-release.yml")
+	source, err := ioutil.ReadFile("../test/golden/indented.yml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if document, err = ParseDocument(source); err != nil {
+		t.Fatal(err)
+	}
+	if err := document.InsertRelease(Release{Version: "1.1.0", Date: "2015-04-01",
+		Sections: []Section{{"added", []string{"Added."}}}}); err != nil {
+		t.Fatal(err)
+	}
+	checkGolden(t, document, "insert-indented.yml")
+	document = loadDocument(t)
+	for _, edit := range []struct {
+		index   int
+		section string
+		entry   string
+	}{
+		{0, "added", "Third added element."},
+		{0, "fixed", "Second fix."},
+		{0, "security", "Second of course."},
+		{0, "deprecated", "Deprecated: with colon."},
+		{1, "notes", "A note."},
+	} {
+		if err := document.AddEntry(edit.index, edit.section, edit.entry); err != nil {
+			t.Fatal(err)
+		}
+	}
+	checkGolden(t, document, "add-entry.yml")
+	document = loadDocument(t)
+	if err := document.SetField(0, "version", "1.0.1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := document.SetField(0, "summary", "Second release, fixed"); err != nil {
+		t.Fatal(err)
+	}
+	if err := document.RemoveField(1, "summary"); err != nil {
+		t.Fatal(err)
+	}
+	if err := document.SetField(1, "summary", "First release"); err != nil {
+		t.Fatal(err)
+	}
+	if err := document.RemoveField(1, "version"); err != nil {
+		t.Fatal(err)
+	}
+	if err := document.SetField(1, "version", "0.1.1"); err != nil {
+		t.Fatal(err)
+	}
+	checkGolden(t, document, "set-field.yml")
+	source, err = ioutil.ReadFile("../test/golden/empty-date.yml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if document, err = ParseDocument(source); err != nil {
+		t.Fatal(err)
+	}
+	if err := document.SetField(0, "version", "1.1.0"); err != nil {
+		t.Fatal(err)
+	}
+	if err := document.SetField(0, "date", "2015-04-01"); err != nil {
+		t.Fatal(err)
+	}
+	checkGolden(t, document, "set-empty-date.yml")
+}
+
+func TestDocumentInsertRelease(t *testing.T) {
+	source, err := ioutil.ReadFile("../test/CHANGELOG.yml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	document, err := ParseDocument(source)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := document.InsertRelease(Release{Version: "1.1.0", Date: "2015-04-01"}); err != nil {
+		t.Fatal(err)
+	}
+	header := "# This Change Log adheres to [Semantic Versioning](http://semver.org).\n\n"
+	release := "- version: 1.1.0\n  date:    2015-04-01\n\n"
+	expected := header + release + strings.TrimPrefix(string(source), header)
+	if string(document.Bytes()) != expected {
+		t.Errorf("Bad document after insert:\n%s", document.Bytes())
+	}
+}
+
+func TestDocumentInsertReleaseEmptyList(t *testing.T) {
+	var tests = map[string]string{
+		"[]\n":             "- version: 0.1.0\n  date:    2015-04-01\n",
+		"# Header\n\n[]\n": "# Header\n\n- version: 0.1.0\n  date:    2015-04-01\n",
+	}
+	for source, expected := range tests {
+		document, err := ParseDocument([]byte(source))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := document.InsertRelease(Release{Version: "0.1.0", Date: "2015-04-01"}); err != nil {
+			t.Fatal(err)
+		}
+		if string(document.Bytes()) != expected {
+			t.Errorf("Bad insert in %q:\n%s", source, document.Bytes())
+		}
+		if changelog, err := document.Changelog(); err != nil || len(changelog) != 1 {
+			t.Errorf("Inserted release should be parsed: %v %v", changelog, err)
+		}
+	}
+	document, err := ParseDocument([]byte("[{version: 1.0.0}]\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := document.InsertRelease(Release{Version: "1.1.0"}); err == nil {
+		t.Errorf("Insert in a flow list should fail")
+	}
+}
+
+func TestDocumentAddEntry(t *testing.T) {
+	source := `# Header
+
+- version: 1.0.0
+  date:    2015-03-30
+  added:
+  - First.
+  fixed:
+  - Fix.
+
+- version: 0.1.0
+  date:    2015-03-29
+`
+	document, err := ParseDocument([]byte(source))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := document.AddEntry(0, "added", "Second: with colon"); err != nil {
+		t.Fatal(err)
+	}
+	if err := document.AddEntry(0, "changed", "Changed."); err != nil {
+		t.Fatal(err)
+	}
+	if err := document.AddEntry(1, "security", "Security."); err != nil {
+		t.Fatal(err)
+	}
+	expected := `# Header
+
+- version: 1.0.0
+  date:    2015-03-30
+  added:
+  - First.
+  - 'Second: with colon'
+  changed:
+  - Changed.
+  fixed:
+  - Fix.
+
+- version: 0.1.0
+  date:    2015-03-29
+  security:
+  - Security.
+`
+	if string(document.Bytes()) != expected {
+		t.Errorf("Bad document after add:\n%s", document.Bytes())
+	}
+	changelog, err := document.Changelog()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(changelog[0].Section("added")) != 2 || changelog[0].Section("added")[1] != "Second: with colon" {
+		t.Errorf("Bad added entries: %v", changelog[0].Section("added"))
+	}
+}
+
+func TestFormatChangelog(t *testing.T) {
+	changelog := Changelog{
+		{Version: "1.0", Date: "2015-03-30", Summary: "Colon: in summary",
+			Sections: []Section{{"added", []string{"Added."}}, {"security", []string{"#1 is not a comment", "- not a list"}}}},
+		{Version: "0.1.0", Date: "2015-03-29", Sections: []Section{{"notes", []string{"Multi\nline", "'quoted'", "yes"}}}},
+	}
+	source := FormatChangelog(changelog)
+	document, err := ParseDocument(source)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkGolden(t, document, "normalized.yml")
+	parsed, err := ParseChangelog(source)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range changelog {
+		if !reflect.DeepEqual(parsed[i], changelog[i]) {
+			t.Errorf("Release %d changed after formatting: %#v", i, parsed[i])
+		}
+	}
+}
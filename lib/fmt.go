@@ -0,0 +1,75 @@
+package lib
+
+import (
+	"fmt"
+	"strings"
+)
+
+// leadingComment returns comment block on top of source, with blank lines,
+// empty if there is none
+func leadingComment(source []byte) string {
+	lines := strings.SplitAfter(string(source), "\n")
+	end := 0
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if trimmed != "" && !strings.HasPrefix(trimmed, "#") {
+			break
+		}
+		if strings.HasPrefix(trimmed, "#") {
+			end = i + 1
+		}
+	}
+	if end == 0 {
+		return ""
+	}
+	return strings.Join(lines[:end], "") + "\n"
+}
+
+// FormatSource returns normalized changelog source, keeping comment block on
+// top of file. Other comments are lost, and changelog must have a valid
+// structure so that no content is lost.
+func FormatSource(source []byte) ([]byte, error) {
+	if _, errors := validateStructure(source); len(errors) > 0 {
+		return nil, fmt.Errorf("can't format changelog with errors:\n%v", errors)
+	}
+	changelog, err := ParseChangelog(source)
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(leadingComment(source)), FormatChangelog(changelog)...), nil
+}
+
+func format(context *Context, args []string) error {
+	flags, args := commandFlags(args)
+	if len(args) > 0 {
+		return usageError("unknown fmt argument %s", args[0])
+	}
+	formatted, err := FormatSource(context.Source)
+	if err != nil {
+		return err
+	}
+	if flags["diff"] != "" {
+		name := context.File
+		if name == "" {
+			name = "stdin"
+		}
+		fmt.Fprint(context.Out, Diff(name, context.Source, formatted))
+	}
+	if flags["check"] != "" {
+		if string(formatted) != string(context.Source) {
+			return fmt.Errorf("changelog is not formatted")
+		}
+		return nil
+	}
+	if flags["diff"] != "" {
+		return nil
+	}
+	if context.File == "" {
+		_, err := context.Out.Write(formatted)
+		return err
+	}
+	if string(formatted) == string(context.Source) {
+		return nil
+	}
+	return WriteChangelog(context.File, formatted)
+}
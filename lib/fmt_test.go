@@ -0,0 +1,68 @@
+package lib
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFormatSource(t *testing.T) {
+	source := `# Leading comment
+# on two lines
+
+- version: "1.0.0"
+  summary: First release # lost comment
+  date: 2020-01-02
+  fixed: [Bug]
+`
+	expected := `# Leading comment
+# on two lines
+
+- version: 1.0.0
+  date:    2020-01-02
+  summary: First release
+  fixed:
+  - Bug
+`
+	formatted, err := FormatSource([]byte(source))
+	if err != nil {
+		t.Fatalf("Formatting source: %v", err)
+	}
+	if string(formatted) != expected {
+		t.Errorf("Bad formatted source:\n%s", formatted)
+	}
+	again, err := FormatSource(formatted)
+	if err != nil || string(again) != string(formatted) {
+		t.Errorf("Formatting should be idempotent:\n%s", again)
+	}
+	if _, err := FormatSource([]byte("- version: 1.0.0\n  foo: bar\n")); err == nil ||
+		!strings.Contains(err.Error(), "unknown key 'foo'") {
+		t.Errorf("Formatting changelog with unknown key should fail, got %v", err)
+	}
+}
+
+func TestDiff(t *testing.T) {
+	if diff := Diff("file", []byte("a\nb\n"), []byte("a\nb\n")); diff != "" {
+		t.Errorf("Diff of same texts should be empty, got:\n%s", diff)
+	}
+	old := "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\n12\n"
+	new := "1\n2\nthree\n4\n5\n6\n7\n8\n9\n10\n11\n12\n13\n"
+	expected := `--- file
++++ file
+@@ -1,6 +1,6 @@
+ 1
+ 2
+-3
++three
+ 4
+ 5
+ 6
+@@ -10,3 +10,4 @@
+ 10
+ 11
+ 12
++13
+`
+	if diff := Diff("file", []byte(old), []byte(new)); diff != expected {
+		t.Errorf("Bad diff:\n%s", diff)
+	}
+}
@@ -0,0 +1,180 @@
+package lib
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// regexpConventionalCommit matches header of a conventional commit, such as
+// 'feat(parser)!: add option'
+var regexpConventionalCommit = regexp.MustCompile(`^(\w+)(?:\(([^)]*)\))?(!)?:\s*(.+)$`)
+
+// regexpBreakingChange matches breaking change footer of a commit message
+var regexpBreakingChange = regexp.MustCompile(`(?m)^BREAKING[ -]CHANGE:\s*(.+)$`)
+
+// BreakingPrefix prefixes entries of breaking changes
+const BreakingPrefix = "BREAKING: "
+
+// CommitSections maps conventional commit types with release sections
+var CommitSections = map[string]string{
+	"feat":     "added",
+	"fix":      "fixed",
+	"perf":     "changed",
+	"remove":   "removed",
+	"security": "security",
+}
+
+// Commit is a parsed conventional commit
+type Commit struct {
+	// Type is the commit type, such as feat or fix
+	Type string
+	// Scope is the optional scope of commit
+	Scope string
+	// Description is the description in commit header
+	Description string
+	// Breaking is the description of breaking change, if any
+	Breaking string
+}
+
+// ParseCommit parses a conventional commit message. Returns nil if message
+// is not a conventional commit.
+func ParseCommit(message string) *Commit {
+	lines := strings.SplitN(strings.TrimSpace(message), "\n", 2)
+	match := regexpConventionalCommit.FindStringSubmatch(strings.TrimSpace(lines[0]))
+	if match == nil {
+		return nil
+	}
+	commit := &Commit{Type: strings.ToLower(match[1]), Scope: match[2], Description: match[4]}
+	if len(lines) > 1 {
+		if breaking := regexpBreakingChange.FindStringSubmatch(lines[1]); breaking != nil {
+			commit.Breaking = strings.TrimSpace(breaking[1])
+		}
+	}
+	if match[3] != "" && commit.Breaking == "" {
+		commit.Breaking = commit.Description
+	}
+	return commit
+}
+
+// Section returns release section for commit, empty if commit type doesn't
+// go in changelog. Breaking changes go in changed section if their type
+// doesn't have a section.
+func (c *Commit) Section() string {
+	section := CommitSections[c.Type]
+	if section == "" && c.Breaking != "" {
+		section = "changed"
+	}
+	return section
+}
+
+// Entry returns changelog entry for commit
+func (c *Commit) Entry() string {
+	entry := c.Description
+	if c.Scope != "" {
+		entry = c.Scope + ": " + entry
+	}
+	if c.Breaking != "" {
+		entry = BreakingPrefix + entry
+		if c.Breaking != c.Description {
+			entry += " (" + c.Breaking + ")"
+		}
+	}
+	return entry
+}
+
+// commitSeparator separates commit messages in git log output
+const commitSeparator = "\x1e"
+
+// Commits returns conventional commits in git history of directory, between
+// from (excluded, all history if empty) and to references, oldest first
+func Commits(dir, from, to string) ([]*Commit, error) {
+	revisions := to
+	if from != "" {
+		revisions = from + ".." + to
+	}
+	output, err := git(dir, "log", "--reverse", "--format=%B"+commitSeparator, revisions)
+	if err != nil {
+		return nil, err
+	}
+	var commits []*Commit
+	for _, message := range strings.Split(output, commitSeparator) {
+		if commit := ParseCommit(message); commit != nil {
+			commits = append(commits, commit)
+		}
+	}
+	return commits, nil
+}
+
+// GenerateRelease groups commits in release sections. Commits which section
+// is not allowed are ignored.
+func GenerateRelease(commits []*Commit) Release {
+	var release Release
+	for _, commit := range commits {
+		section := commit.Section()
+		if indexOf(Sections, section) < 0 {
+			continue
+		}
+		if indexOf(release.Section(section), commit.Entry()) < 0 {
+			release.Add(section, commit.Entry())
+		}
+	}
+	return release
+}
+
+func generate(context *Context, args []string) error {
+	flags, args := commandFlags(args)
+	if len(args) > 0 {
+		return usageError("unknown generate argument %s", args[0])
+	}
+	dir := context.gitDir()
+	from, to := flags["from"], flags["to"]
+	released := context.Changelog
+	if len(released) > 0 && released[0].Unreleased() {
+		released = released[1:]
+	}
+	if to == "" {
+		to = "HEAD"
+	}
+	// default is to start from tag of top release, if any
+	if from == "" && len(released) > 0 {
+		if tag := Repository.TagName(released[0].Version); gitRefExists(dir, tag) {
+			from = tag
+		}
+	}
+	commits, err := Commits(dir, from, to)
+	if err != nil {
+		return fmt.Errorf("reading git history: %v", err)
+	}
+	generated := GenerateRelease(commits)
+	if flags["merge"] == "" {
+		level, _ := SuggestLevel(generated)
+		version, err := nextVersion(released, []string{level})
+		if err != nil {
+			return fmt.Errorf("computing next version: %v", err)
+		}
+		generated.Version = version
+		generated.Date = now().Local().Format(DateFormat)
+		_, err = context.Out.Write(FormatChangelog(Changelog{generated}))
+		return err
+	}
+	if len(context.Changelog) == 0 {
+		return fmt.Errorf("no release to merge entries into")
+	}
+	document, err := context.Document()
+	if err != nil {
+		return err
+	}
+	release := context.Changelog[0]
+	for _, section := range generated.Sections {
+		for _, entry := range section.Entries {
+			if indexOf(release.Section(section.Name), entry) >= 0 {
+				continue
+			}
+			if err := document.AddEntry(context.Shift, section.Name, entry); err != nil {
+				return fmt.Errorf("adding entry: %v", err)
+			}
+		}
+	}
+	return context.Write(document)
+}
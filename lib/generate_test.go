@@ -0,0 +1,81 @@
+package lib
+
+import (
+	"os/exec"
+	"reflect"
+	"testing"
+)
+
+func TestParseCommit(t *testing.T) {
+	var tests = []struct {
+		message string
+		section string
+		entry   string
+	}{
+		{"feat: add option", "added", "add option"},
+		{"fix(parser): crash on empty file\n\nDetails.", "fixed", "parser: crash on empty file"},
+		{"perf: faster parsing", "changed", "faster parsing"},
+		{"refactor!: drop old API", "changed", "BREAKING: drop old API"},
+		{"feat: new API\n\nBREAKING CHANGE: old API removed", "added", "BREAKING: new API (old API removed)"},
+		{"docs: update readme", "", "update readme"},
+	}
+	for _, test := range tests {
+		commit := ParseCommit(test.message)
+		if commit == nil {
+			t.Errorf("Message %q should be a conventional commit", test.message)
+			continue
+		}
+		if commit.Section() != test.section || commit.Entry() != test.entry {
+			t.Errorf("Commit %q gave section %q and entry %q", test.message, commit.Section(), commit.Entry())
+		}
+	}
+	if commit := ParseCommit("Merge branch 'master'"); commit != nil {
+		t.Errorf("Merge commit should not be a conventional commit")
+	}
+}
+
+// initRepository creates a git repository with commits in a temporary
+// directory, tagging first commit with tag
+func initRepository(t *testing.T, tag string, messages ...string) string {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	dir := t.TempDir()
+	if _, err := git(dir, "init", "--quiet"); err != nil {
+		t.Fatal(err)
+	}
+	for index, message := range messages {
+		if _, err := git(dir, "-c", "user.name=test", "-c", "user.email=test@example.com",
+			"commit", "--quiet", "--allow-empty", "-m", message); err != nil {
+			t.Fatal(err)
+		}
+		if index == 0 && tag != "" {
+			if _, err := git(dir, "tag", tag); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	return dir
+}
+
+func TestGenerateRelease(t *testing.T) {
+	dir := initRepository(t, "v1.0.0", "chore: initial commit", "feat: add option",
+		"fix: crash", "docs: readme", "not conventional", "fix: crash")
+	commits, err := Commits(dir, "v1.0.0", "HEAD")
+	if err != nil {
+		t.Fatal(err)
+	}
+	release := GenerateRelease(commits)
+	expected := Release{Sections: []Section{{"added", []string{"add option"}}, {"fixed", []string{"crash"}}}}
+	if !reflect.DeepEqual(release, expected) {
+		t.Errorf("Bad generated release: %+v", release)
+	}
+	commits, err = Commits(dir, "", "HEAD")
+	if err != nil || len(commits) != 5 {
+		t.Errorf("Whole history should have 5 conventional commits, got %d (%v)", len(commits), err)
+	}
+	if _, err := Commits(dir, "v2.0.0", "HEAD"); err == nil {
+		t.Errorf("Unknown reference should fail")
+	}
+}
@@ -0,0 +1,42 @@
+package lib
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// git runs git command with arguments in given directory and returns its
+// output
+func git(dir string, args ...string) (string, error) {
+	command := exec.Command("git", args...)
+	command.Dir = dir
+	var stdout, stderr bytes.Buffer
+	command.Stdout = &stdout
+	command.Stderr = &stderr
+	if err := command.Run(); err != nil {
+		message := strings.TrimSpace(stderr.String())
+		if message == "" {
+			message = err.Error()
+		}
+		return "", fmt.Errorf("running git %s: %s", strings.Join(args, " "), message)
+	}
+	return stdout.String(), nil
+}
+
+// gitDir returns directory where to run git for context: the directory of
+// changelog file, or current directory if changelog was piped
+func (c *Context) gitDir() string {
+	if c.File == "" {
+		return "."
+	}
+	return filepath.Dir(c.File)
+}
+
+// gitRefExists tells if git reference, such as a tag, exists
+func gitRefExists(dir, ref string) bool {
+	_, err := git(dir, "rev-parse", "--verify", "--quiet", ref+"^{commit}")
+	return err == nil
+}
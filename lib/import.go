@@ -0,0 +1,159 @@
+package lib
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+var (
+	// regexpMarkdownRelease matches release headings such as '## [1.2.0] - 2024-01-01'
+	regexpMarkdownRelease = regexp.MustCompile(`^##\s+\[?([^\]\s]+)\]?(?:\s+-\s+(\S+))?\s*(.*)$`)
+	// regexpMarkdownSection matches section headings such as '### Added'
+	regexpMarkdownSection = regexp.MustCompile(`^###\s+(.+?)\s*$`)
+	// regexpMarkdownEntry matches list items
+	regexpMarkdownEntry = regexp.MustCompile(`^[-*+]\s+(.*)$`)
+	// regexpMarkdownLink matches link reference definitions
+	regexpMarkdownLink = regexp.MustCompile(`^\[[^\]]+\]:\s*\S+`)
+)
+
+// ImportMarkdown parses a Keep a Changelog markdown source into a changelog.
+// Unreleased section becomes an unreleased release and link reference
+// definitions are ignored. Returns lines that could not be mapped.
+func ImportMarkdown(source []byte) (Changelog, ValidationErrors) {
+	var changelog Changelog
+	var unmapped ValidationErrors
+	unreleased := -1
+	unreleasedLine := 0
+	section := ""
+	mapped := false
+	continued := false
+	for number, line := range strings.Split(string(source), "\n") {
+		number++
+		trimmed := strings.TrimSpace(line)
+		report := func(format string, args ...interface{}) {
+			err := ValidationError{Release: len(changelog) - 1, Line: number,
+				Message: fmt.Sprintf(format, args...)}
+			if err.Release >= 0 {
+				err.Version = changelog[err.Release].Version
+			}
+			unmapped = append(unmapped, err)
+		}
+		if trimmed == "" {
+			continued = false
+			continue
+		}
+		if regexpMarkdownLink.MatchString(trimmed) {
+			continue
+		}
+		if match := regexpMarkdownRelease.FindStringSubmatch(line); match != nil {
+			release := Release{Version: match[1], Date: match[2]}
+			if isUnreleased(release.Version) {
+				release.Version = UnreleasedVersion
+				unreleased = len(changelog)
+				unreleasedLine = number
+			} else if date, err := time.Parse(ISODateFormat, release.Date); err == nil {
+				release.Date = date.Format(DateFormat)
+			}
+			changelog = append(changelog, release)
+			section = ""
+			mapped = false
+			continued = false
+			if match[3] != "" {
+				report("could not map '%s' in release heading", match[3])
+			}
+			continue
+		}
+		if len(changelog) == 0 && strings.HasPrefix(line, "# ") {
+			continue
+		}
+		if match := regexpMarkdownSection.FindStringSubmatch(line); match != nil && len(changelog) > 0 {
+			section = sectionByTitle(match[1])
+			mapped = sectionIndex(section) >= 0
+			if !mapped {
+				report("unknown section '%s'", section)
+			}
+			continued = false
+			continue
+		}
+		if match := regexpMarkdownEntry.FindStringSubmatch(line); match != nil && mapped {
+			changelog[len(changelog)-1].Add(section, match[1])
+			continued = true
+			continue
+		}
+		if continued && (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) {
+			entries := changelog[len(changelog)-1].Section(section)
+			entries[len(entries)-1] += " " + trimmed
+			continue
+		}
+		if len(changelog) > 0 && section == "" {
+			release := &changelog[len(changelog)-1]
+			release.Summary = strings.TrimSpace(release.Summary + " " + trimmed)
+			continue
+		}
+		if section != "" && !mapped {
+			report("could not map line in unknown section '%s'", section)
+		} else {
+			report("could not map line '%s'", trimmed)
+		}
+	}
+	if unreleased > 0 {
+		unmapped = append(unmapped, ValidationError{Release: unreleased, Version: UnreleasedVersion,
+			Line: unreleasedLine, Message: "unreleased section must be on top, ignored"})
+		changelog = append(changelog[:unreleased], changelog[unreleased+1:]...)
+	}
+	return changelog, unmapped
+}
+
+func importChangelog(context *Context, args []string) error {
+	if len(args) != 2 {
+		return usageError("you must pass format and file to import")
+	}
+	if args[0] != "markdown" {
+		return usageError("unknown import format %s (must be markdown)", args[0])
+	}
+	file := context.Options.File
+	dir := "."
+	if file != "" {
+		if _, err := os.Stat(file); err == nil {
+			return fmt.Errorf("changelog file '%s' already exists", file)
+		}
+		dir = filepath.Dir(file)
+	}
+	config, err := LoadConfig(dir)
+	if err != nil {
+		return err
+	}
+	if err := config.Apply(); err != nil {
+		return err
+	}
+	source, err := ioutil.ReadFile(filepath.Clean(args[1]))
+	if err != nil {
+		return fmt.Errorf("reading file '%s'", args[1])
+	}
+	changelog, unmapped := ImportMarkdown(source)
+	for _, err := range unmapped {
+		fmt.Fprintf(context.Err, "WARNING: %v\n", err)
+	}
+	formatted := FormatChangelog(changelog)
+	if file != "" {
+		return WriteChangelog(file, formatted)
+	}
+	_, err = context.Out.Write(formatted)
+	return err
+}
+
+// sectionByTitle returns name of section with given title, such as added for
+// Added, comparing titles case insensitively
+func sectionByTitle(title string) string {
+	for _, name := range Sections {
+		if strings.EqualFold(SectionTitle(name), title) {
+			return name
+		}
+	}
+	return strings.ToLower(title)
+}
@@ -0,0 +1,64 @@
+package lib
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestImportMarkdown(t *testing.T) {
+	source := `# Changelog
+
+All notable changes to this project will be documented in this file.
+
+## [Unreleased]
+### Added
+- New feature
+
+## [1.1.0] - 2024-02-01
+Summary of release.
+
+### Added
+- Feature that spans
+  two lines.
+* Other feature
+### Fixed
+- Bug
+### Weird
+- Stuff
+
+## 1.0.0 - 2024-01-01 [YANKED]
+### Removed
+- Old feature
+
+[unreleased]: https://github.com/owner/repo/compare/v1.1.0...HEAD
+[1.1.0]: https://github.com/owner/repo/compare/v1.0.0...v1.1.0
+`
+	expected := Changelog{
+		{Version: "unreleased", Sections: []Section{{"added", []string{"New feature"}}}},
+		{Version: "1.1.0", Date: "2024-02-01", Summary: "Summary of release.",
+			Sections: []Section{{"added", []string{"Feature that spans two lines.", "Other feature"}}, {"fixed", []string{"Bug"}}}},
+		{Version: "1.0.0", Date: "2024-01-01", Sections: []Section{{"removed", []string{"Old feature"}}}},
+	}
+	changelog, unmapped := ImportMarkdown([]byte(source))
+	if !reflect.DeepEqual(changelog, expected) {
+		t.Errorf("Bad imported changelog: %+v", changelog)
+	}
+	var lines []int
+	for _, err := range unmapped {
+		lines = append(lines, err.Line)
+	}
+	if !reflect.DeepEqual(lines, []int{3, 18, 19, 21}) {
+		t.Errorf("Bad unmapped lines: %v", unmapped)
+	}
+}
+
+func TestImportMarkdownUnreleasedNotOnTop(t *testing.T) {
+	source := "## [1.0.0] - 2024-01-01\n### Added\n- Feature\n## [Unreleased]\n### Fixed\n- Bug\n"
+	changelog, unmapped := ImportMarkdown([]byte(source))
+	if len(changelog) != 1 || changelog[0].Version != "1.0.0" {
+		t.Errorf("Unreleased section should be ignored: %+v", changelog)
+	}
+	if len(unmapped) != 1 || unmapped[0].Line != 4 {
+		t.Errorf("Unreleased section should be reported: %v", unmapped)
+	}
+}
@@ -0,0 +1,14 @@
+package lib
+
+import (
+	"encoding/json"
+	"io"
+)
+
+// writeJSON writes value as indented JSON
+func writeJSON(out io.Writer, value interface{}) error {
+	encoder := json.NewEncoder(out)
+	encoder.SetIndent("", "  ")
+	encoder.SetEscapeHTML(false)
+	return encoder.Encode(value)
+}
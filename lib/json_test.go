@@ -0,0 +1,35 @@
+package lib
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestReleaseJSON(t *testing.T) {
+	var out bytes.Buffer
+	context := Context{Out: &out, Options: &Options{Format: "json"},
+		Changelog: Changelog{{Version: "1.0.0", Date: "2015-03-30", Summary: "<First> release",
+			Sections: []Section{{"fixed", []string{"Fix."}}}}}}
+	if err := release(&context, nil); err != nil {
+		t.Fatal(err)
+	}
+	expected := `{
+  "version": "1.0.0",
+  "date": "2015-03-30",
+  "summary": "<First> release",
+  "added": [],
+  "changed": [],
+  "deprecated": [],
+  "removed": [],
+  "fixed": [
+    "Fix."
+  ],
+  "security": [],
+  "rejected": [],
+  "notes": []
+}
+`
+	if out.String() != expected {
+		t.Errorf("Bad release JSON:\n%s", out.String())
+	}
+}
@@ -0,0 +1,67 @@
+package lib
+
+import (
+	"html"
+	"regexp"
+	"strings"
+)
+
+var (
+	// regexpMarkdownCode matches inline code spans
+	regexpMarkdownCode = regexp.MustCompile("`([^`]+)`")
+	// regexpMarkdownLinkInline matches inline links such as [text](url)
+	regexpMarkdownLinkInline = regexp.MustCompile(`\[([^\]]+)\]\(([^)\s]+)\)`)
+	// regexpMarkdownStrong matches strong emphasis such as **text**
+	regexpMarkdownStrong = regexp.MustCompile(`\*\*([^*]+)\*\*|__([^_]+)__`)
+	// regexpMarkdownEmphasis matches emphasis such as *text*, or _text_ outside words
+	regexpMarkdownEmphasis = regexp.MustCompile(`\*([^*]+)\*|(^|[^\w])_([^_]+)_($|[^\w])`)
+	// regexpSafeURL matches URLs that are safe in links
+	regexpSafeURL = regexp.MustCompile(`^(?i)(https?://|mailto:|[^:]*$)`)
+)
+
+// MarkdownToHTML renders inline markdown (code, emphasis and links) of text
+// in HTML. Text is escaped, so that HTML in text is displayed as is, and links
+// with unsafe URLs (such as javascript:) are not rendered.
+func MarkdownToHTML(text string) string {
+	var builder strings.Builder
+	// code spans are rendered as is, other markup is rendered between them
+	last := 0
+	for _, span := range regexpMarkdownCode.FindAllStringSubmatchIndex(text, -1) {
+		builder.WriteString(inlineToHTML(text[last:span[0]]))
+		builder.WriteString("<code>" + html.EscapeString(text[span[2]:span[3]]) + "</code>")
+		last = span[1]
+	}
+	builder.WriteString(inlineToHTML(text[last:]))
+	return builder.String()
+}
+
+// inlineToHTML renders links and emphasis of text without code spans
+func inlineToHTML(text string) string {
+	var builder strings.Builder
+	last := 0
+	for _, link := range regexpMarkdownLinkInline.FindAllStringSubmatchIndex(text, -1) {
+		builder.WriteString(emphasisToHTML(text[last:link[0]]))
+		label, url := text[link[2]:link[3]], text[link[4]:link[5]]
+		if regexpSafeURL.MatchString(url) {
+			builder.WriteString(`<a href="` + html.EscapeString(url) + `">` + emphasisToHTML(label) + "</a>")
+		} else {
+			builder.WriteString(emphasisToHTML(text[link[0]:link[1]]))
+		}
+		last = link[1]
+	}
+	builder.WriteString(emphasisToHTML(text[last:]))
+	return builder.String()
+}
+
+// emphasisToHTML escapes text and renders its emphasis
+func emphasisToHTML(text string) string {
+	text = html.EscapeString(text)
+	text = regexpMarkdownStrong.ReplaceAllString(text, "<strong>$1$2</strong>")
+	return regexpMarkdownEmphasis.ReplaceAllStringFunc(text, func(match string) string {
+		parts := regexpMarkdownEmphasis.FindStringSubmatch(match)
+		if parts[1] != "" {
+			return "<em>" + parts[1] + "</em>"
+		}
+		return parts[2] + "<em>" + parts[3] + "</em>" + parts[4]
+	})
+}
@@ -0,0 +1,23 @@
+package lib
+
+import "testing"
+
+func TestMarkdownToHTML(t *testing.T) {
+	var tests = map[string]string{
+		"Plain text":                               "Plain text",
+		"Use `a < b` here":                         "Use <code>a &lt; b</code> here",
+		"Code `*not emphasis*`":                    "Code <code>*not emphasis*</code>",
+		"Some *emphasis* and **strong**":           "Some <em>emphasis</em> and <strong>strong</strong>",
+		"_emphasis_ but not snake_case_x":          "<em>emphasis</em> but not snake_case_x",
+		"See [docs](https://example.com/?a=1&b=2)": `See <a href="https://example.com/?a=1&amp;b=2">docs</a>`,
+		"[relative](docs/index.html)":              `<a href="docs/index.html">relative</a>`,
+		"<script>alert('x')</script>":              "&lt;script&gt;alert(&#39;x&#39;)&lt;/script&gt;",
+		"[click](javascript:alert(1))":             "[click](javascript:alert(1))",
+		`[x](http://a"onclick="alert(1))`:          `<a href="http://a&#34;onclick=&#34;alert(1">x</a>)`,
+	}
+	for markdown, expected := range tests {
+		if html := MarkdownToHTML(markdown); html != expected {
+			t.Errorf("Markdown %q should render %q, got %q", markdown, expected, html)
+		}
+	}
+}
@@ -0,0 +1,62 @@
+package lib
+
+import (
+	"fmt"
+)
+
+// InitialVersion is the version of first release
+const InitialVersion = "0.1.0"
+
+// nextVersion computes version of a new release from top release version
+func nextVersion(changelog Changelog, args []string) (string, error) {
+	level := "patch"
+	if len(args) > 0 {
+		if Scheme.Check(args[0]) == nil {
+			return args[0], nil
+		}
+		level = args[0]
+	}
+	if len(changelog) == 0 {
+		if Scheme.Check(InitialVersion) != nil {
+			return "", fmt.Errorf("you must pass version of first release")
+		}
+		return InitialVersion, nil
+	}
+	if err := Scheme.Check(changelog[0].Version); err != nil {
+		return "", fmt.Errorf("top release version %v", err)
+	}
+	return Scheme.Bump(changelog[0].Version, level)
+}
+
+func newRelease(context *Context, args []string) error {
+	if len(args) > 1 {
+		return usageError("too many arguments")
+	}
+	document, err := context.Document()
+	if err != nil {
+		return err
+	}
+	current, err := document.Changelog()
+	if err != nil {
+		return err
+	}
+	if len(current) > 0 && current[0].Unreleased() {
+		return fmt.Errorf("top release is unreleased, use 'release cut' to release it")
+	}
+	// an unreleased release has no version nor date yet
+	release := Release{Version: UnreleasedVersion}
+	if len(args) == 0 || !isUnreleased(args[0]) {
+		release.Version, err = nextVersion(current, args)
+		if err != nil {
+			return fmt.Errorf("computing next version: %v", err)
+		}
+		release.Date = now().Local().Format(DateFormat)
+	}
+	if errors := checkOrder(append(Changelog{release}, current...)); len(errors) > 0 {
+		return fmt.Errorf("new release: %v", errors)
+	}
+	if err := document.InsertRelease(release); err != nil {
+		return fmt.Errorf("inserting release: %v", err)
+	}
+	return context.Write(document)
+}
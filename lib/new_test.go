@@ -0,0 +1,28 @@
+package lib
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNewRelease(t *testing.T) {
+	now = func() time.Time { return time.Date(2024, 3, 1, 12, 0, 0, 0, time.Local) }
+	defer func() { now = time.Now }()
+	source := "- version: 1.2.3-RC\n  date:    2024-02-01\n"
+	file := filepath.Join(t.TempDir(), "CHANGELOG.yml")
+	context := &Context{Options: &Options{}, File: file, Source: []byte(source)}
+	if err := newRelease(context, []string{"snapshot"}); err != nil {
+		t.Fatal(err)
+	}
+	written, _ := ioutil.ReadFile(file)
+	if !strings.HasPrefix(string(written), "- version: 1.2.4-SNAPSHOT\n  date:    2024-03-01\n") {
+		t.Errorf("Bad new release:\n%s", written)
+	}
+	err := newRelease(context, []string{"1.0.0"})
+	if err == nil || !strings.Contains(err.Error(), "should be lower than previous release version") {
+		t.Errorf("New release with lower version should fail, got %v", err)
+	}
+}
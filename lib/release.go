@@ -3,77 +3,223 @@ package lib
 import (
 	"fmt"
 	"regexp"
+	"strings"
 	"time"
 )
 
 // RegexpDate is a regexp for date
 var RegexpDate = regexp.MustCompile(`^\d\d\d\d-\d\d-\d\d$`)
 
+// ISODateFormat is the layout of ISO dates
+const ISODateFormat = "2006-01-02"
+
+// DateFormat is the layout of release dates
+var DateFormat = ISODateFormat
+
+// now returns current time, replaced in tests
+var now = time.Now
+
 // RegexSuffixes is a regexp for version suffixes
 var RegexSuffixes = `SNAPSHOT|ALPHA|BETA|RC|snapshot|alpha|beta|rc`
 
 // RegexpVersion is a regexp for version
 var RegexpVersion = regexp.MustCompile(`^\d+(\.\d+)*(-(` + RegexSuffixes + `)(-\d+)?)?$`)
 
-func checkRelease(release Release) error {
-	if release.Version == "" {
-		return fmt.Errorf("Release version is empty")
+// UnreleasedVersion is the version of top release gathering unreleased
+// changes, which has no date
+const UnreleasedVersion = "unreleased"
+
+// isUnreleased tells if version is the one of unreleased changes
+func isUnreleased(version string) bool {
+	return strings.EqualFold(version, UnreleasedVersion)
+}
+
+// Unreleased tells if release gathers unreleased changes
+func (r Release) Unreleased() bool {
+	return isUnreleased(r.Version)
+}
+
+func checkRelease(index int, release Release) ValidationErrors {
+	var errors ValidationErrors
+	report := func(field, format string, args ...interface{}) {
+		errors = append(errors, ValidationError{Release: index, Version: release.Version,
+			Field: field, Message: fmt.Sprintf(format, args...)})
 	}
-	if !RegexpVersion.MatchString(release.Version) {
-		return fmt.Errorf("Release version '%s' is not a valid semantic version number",
-			release.Version)
+	if release.Unreleased() {
+		if index > 0 {
+			report("version", "Only top release may be unreleased")
+		}
+		if release.Date != "" {
+			report("date", "Unreleased release must not have a date")
+		}
+		return errors
+	}
+	if release.Version == "" {
+		report("version", "Release version is empty")
+	} else if err := Scheme.Check(release.Version); err != nil {
+		report("version", "Release %v", err)
 	}
 	if release.Date == "" {
-		return fmt.Errorf("Release date is empty")
+		report("date", "Release date is empty")
+	} else if DateFormat == ISODateFormat && !RegexpDate.MatchString(release.Date) {
+		report("date", "Release date '%s' is not valid ISO format", release.Date)
+	} else if _, err := time.Parse(DateFormat, release.Date); err != nil {
+		if DateFormat == ISODateFormat {
+			report("date", "Release date '%s' is not a valid calendar date", release.Date)
+		} else {
+			report("date", "Release date '%s' doesn't match date format %s", release.Date, DateFormat)
+		}
 	}
-	if !RegexpDate.MatchString(release.Date) {
-		return fmt.Errorf("Release date '%s' is not valid ISO format", release.Date)
+	return errors
+}
+
+// checkChangelog checks all releases and returns ValidationErrors if any,
+// with their position in source. Changelog starts at release shift of source.
+func checkChangelog(changelog Changelog, source []byte, shift int) error {
+	errors := checkReleases(changelog)
+	if len(errors) > 0 {
+		return locateErrors(errors, source, shift)
 	}
 	return nil
 }
 
-func checkChangelog(changelog Changelog) error {
+func checkReleases(changelog Changelog) ValidationErrors {
 	if len(changelog) == 0 {
-		return fmt.Errorf("Release is empy")
+		return ValidationErrors{{Release: -1, Message: "Release is empy"}}
+	}
+	var errors ValidationErrors
+	for index, release := range changelog {
+		errors = append(errors, checkRelease(index, release)...)
 	}
-	for _, release := range changelog {
-		err := checkRelease(release)
+	errors = append(errors, checkOrder(changelog)...)
+	return append(errors, checkDates(changelog)...)
+}
+
+// isSnapshot tells if version is a valid snapshot version for the scheme,
+// such as 1.2.0-SNAPSHOT or 1.2.0-SNAPSHOT-1, that is not released yet
+func isSnapshot(version string) bool {
+	return Scheme.Check(version) == nil && Scheme.Snapshot(version)
+}
+
+// checkDates checks that release dates are not in the future, except for a
+// top SNAPSHOT release, and that no release is dated after the one above it
+func checkDates(changelog Changelog) ValidationErrors {
+	var errors ValidationErrors
+	today, _ := time.Parse(DateFormat, now().Local().Format(DateFormat))
+	var previous *time.Time
+	for index, release := range changelog {
+		date, err := time.Parse(DateFormat, release.Date)
 		if err != nil {
-			return err
+			previous = nil
+			continue
+		}
+		report := func(format string, args ...interface{}) {
+			errors = append(errors, ValidationError{Release: index, Version: release.Version,
+				Field: "date", Message: fmt.Sprintf(format, args...)})
+		}
+		if date.After(today) && !(index == 0 && isSnapshot(release.Version)) {
+			report("Release date %s is in the future", release.Date)
 		}
+		if previous != nil && date.After(*previous) {
+			report("Release date %s is after date of next release %s", release.Date,
+				previous.Format(DateFormat))
+		}
+		previous = &date
 	}
-	return nil
+	return errors
+}
+
+// checkOrder checks that release versions are unique and in descending order
+func checkOrder(changelog Changelog) ValidationErrors {
+	var errors ValidationErrors
+	var versions []string
+	previous := ""
+	for index, release := range changelog {
+		version := release.Version
+		if Scheme.Check(version) != nil {
+			previous = ""
+			continue
+		}
+		report := func(format string, args ...interface{}) {
+			errors = append(errors, ValidationError{Release: index, Version: release.Version,
+				Field: "version", Message: fmt.Sprintf(format, args...)})
+		}
+		duplicate := false
+		for _, other := range versions {
+			if Scheme.Compare(version, other) == 0 {
+				report("Release version '%s' is a duplicate of '%s'", release.Version, other)
+				duplicate = true
+				break
+			}
+		}
+		if !duplicate && previous != "" && Scheme.Compare(version, previous) > 0 {
+			report("Release version '%s' should be lower than previous release version '%s'",
+				release.Version, previous)
+		}
+		versions = append(versions, version)
+		previous = version
+	}
+	return errors
 }
 
-func release(changelog Changelog, args []string) error {
-	if err := checkChangelog(changelog); err != nil {
+func release(context *Context, args []string) error {
+	flags, args := commandFlags(args)
+	subcommand := ""
+	if len(args) > 0 {
+		subcommand = args[0]
+	}
+	if (flags["tag"] != "" || flags["dry-run"] != "") && subcommand != "cut" {
+		return usageError("options --tag and --dry-run are only for cut")
+	}
+	if flags["template"] != "" && subcommand != "to" && subcommand != "desc" && subcommand != "cut" {
+		return usageError("option --template is only for to, desc and cut")
+	}
+	changelog := context.Changelog
+	if err := checkChangelog(changelog, context.Source, context.Shift); err != nil {
 		return fmt.Errorf("checking changelog: %v", err)
 	}
+	// release information is about last release, not unreleased changes
+	if len(changelog) > 0 && changelog[0].Unreleased() &&
+		!(len(args) > 0 && (args[0] == "cut" || args[0] == "suggest")) {
+		changelog = changelog[1:]
+		if len(changelog) == 0 {
+			return fmt.Errorf("no release yet, only unreleased changes")
+		}
+	}
+	if len(args) == 0 && context.Options.Format == "json" {
+		if err := writeJSON(context.Out, changelog[0]); err != nil {
+			return fmt.Errorf("generating JSON: %v", err)
+		}
+	}
 	if len(args) > 0 && len(changelog) > 0 {
 		if args[0] == "summary" {
-			fmt.Println((changelog)[0].Summary)
+			fmt.Fprintln(context.Out, (changelog)[0].Summary)
 		} else if args[0] == "date" {
 			if len(args) > 1 {
-				date := time.Now().Local().Format("2006-01-02")
+				date := now().Local().Format(DateFormat)
 				if date != (changelog)[0].Date {
 					return fmt.Errorf("Release date %s is wrong (should be %s)",
 						(changelog)[0].Date, date)
 				}
 			} else {
-				fmt.Println((changelog)[0].Date)
+				fmt.Fprintln(context.Out, (changelog)[0].Date)
 			}
 		} else if args[0] == "version" {
-			fmt.Println((changelog)[0].Version)
+			fmt.Fprintln(context.Out, (changelog)[0].Version)
 		} else if args[0] == "to" {
-			if err := releaseToMarkdown((changelog)[0]); err != nil {
+			if err := releaseToMarkdown(context.Out, (changelog)[0], flags["template"]); err != nil {
 				return fmt.Errorf("generating markdown: %v", err)
 			}
+		} else if args[0] == "cut" {
+			return cutRelease(context, flags, args[1:])
+		} else if args[0] == "suggest" {
+			return suggest(context)
 		} else if args[0] == "desc" {
-			if err := descriptionToMarkdown((changelog)[0]); err != nil {
+			if err := descriptionToMarkdown(context.Out, (changelog)[0], flags["template"]); err != nil {
 				return fmt.Errorf("generating markdown: %v", err)
 			}
 		} else {
-			return fmt.Errorf("unknown release argument %s", args[0])
+			return usageError("unknown release argument %s", args[0])
 		}
 	}
 	return nil
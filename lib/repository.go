@@ -0,0 +1,59 @@
+package lib
+
+import (
+	"strings"
+)
+
+// Repository is the configuration of source repository
+var Repository RepositoryConfig
+
+// TagName returns name of release tag for version
+func (r RepositoryConfig) TagName(version string) string {
+	return r.Tag + version
+}
+
+// CompareURL returns URL comparing two tags, empty if not configured
+func (r RepositoryConfig) CompareURL(from, to string) string {
+	if r.Compare == "" {
+		return ""
+	}
+	return strings.NewReplacer("{from}", from, "{to}", to).Replace(r.Compare)
+}
+
+// ReleaseURL returns URL of release tag, empty if not configured
+func (r RepositoryConfig) ReleaseURL(tag string) string {
+	if r.Release == "" {
+		return ""
+	}
+	return strings.ReplaceAll(r.Release, "{tag}", tag)
+}
+
+// Link is a link reference definition in markdown
+type Link struct {
+	Name string
+	URL  string
+}
+
+// releaseLinks returns links to compare unreleased changes with top release
+// and each release with previous one, in changelog order
+func releaseLinks(changelog Changelog) []Link {
+	var links []Link
+	if len(changelog) > 0 {
+		if url := Repository.CompareURL(Repository.TagName(changelog[0].Version), "HEAD"); url != "" {
+			links = append(links, Link{Name: strings.ToLower(UnreleasedVersion), URL: url})
+		}
+	}
+	for index, release := range changelog {
+		tag := Repository.TagName(release.Version)
+		url := ""
+		if index < len(changelog)-1 {
+			url = Repository.CompareURL(Repository.TagName(changelog[index+1].Version), tag)
+		} else {
+			url = Repository.ReleaseURL(tag)
+		}
+		if url != "" {
+			links = append(links, Link{Name: release.Version, URL: url})
+		}
+	}
+	return links
+}
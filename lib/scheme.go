@@ -0,0 +1,433 @@
+package lib
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// VersionScheme validates, compares and bumps release versions
+type VersionScheme interface {
+	// Check returns an error if version is not valid for this scheme
+	Check(version string) error
+	// Compare returns -1, 0 or 1 if valid version a is lower, equal or
+	// greater than valid version b
+	Compare(a, b string) int
+	// Bump returns next version for given level (such as major or rc)
+	Bump(version, level string) (string, error)
+	// Prerelease tells if valid version is a pre-release
+	Prerelease(version string) bool
+	// Final returns valid version without its pre-release part
+	Final(version string) string
+	// Snapshot tells if valid version is a snapshot, that is not released yet
+	Snapshot(version string) bool
+}
+
+// Scheme is the version scheme in use, loose scheme by default
+var Scheme VersionScheme = LooseScheme{}
+
+// NewVersionScheme returns version scheme with given name, which is loose,
+// semver, calver or regex. Pattern is the regular expression of regex scheme
+// and prefix is an optional prefix of all versions, such as v.
+func NewVersionScheme(name, pattern, prefix string) (VersionScheme, error) {
+	var scheme VersionScheme
+	switch name {
+	case "", "loose":
+		scheme = LooseScheme{}
+	case "semver":
+		scheme = SemVerScheme{}
+	case "calver":
+		scheme = CalVerScheme{}
+	case "regex":
+		if pattern == "" {
+			return nil, fmt.Errorf("regex version scheme needs a pattern")
+		}
+		regex, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("bad version pattern '%s': %v", pattern, err)
+		}
+		scheme = RegexScheme{Regexp: regex}
+	default:
+		return nil, fmt.Errorf("unknown version scheme '%s' (must be loose, semver, calver or regex)", name)
+	}
+	if pattern != "" && name != "regex" {
+		return nil, fmt.Errorf("version pattern is only for regex version scheme")
+	}
+	if prefix != "" {
+		scheme = PrefixScheme{Prefix: prefix, Scheme: scheme}
+	}
+	return scheme, nil
+}
+
+// LooseScheme is the historical scheme of this tool: numbers separated with
+// dots and an optional suffix such as SNAPSHOT or RC-1
+type LooseScheme struct{}
+
+// Check returns an error if version doesn't match RegexpVersion
+func (s LooseScheme) Check(version string) error {
+	_, err := ParseVersion(version)
+	return err
+}
+
+// Compare compares versions
+func (s LooseScheme) Compare(a, b string) int {
+	va, _ := ParseVersion(a)
+	vb, _ := ParseVersion(b)
+	return va.Compare(vb)
+}
+
+// Bump bumps version
+func (s LooseScheme) Bump(version, level string) (string, error) {
+	v, err := ParseVersion(version)
+	if err != nil {
+		return "", err
+	}
+	next, err := v.Bump(level)
+	if err != nil {
+		return "", err
+	}
+	return next.String(), nil
+}
+
+// Prerelease tells if version has a suffix
+func (s LooseScheme) Prerelease(version string) bool {
+	v, _ := ParseVersion(version)
+	return v.Suffix != ""
+}
+
+// Final returns version without suffix
+func (s LooseScheme) Final(version string) string {
+	v, _ := ParseVersion(version)
+	return Version{Numbers: v.Numbers}.String()
+}
+
+// Snapshot tells if version suffix is SNAPSHOT, with or without counter
+func (s LooseScheme) Snapshot(version string) bool {
+	v, _ := ParseVersion(version)
+	return strings.EqualFold(v.Suffix, "SNAPSHOT")
+}
+
+// RegexpSemVer is the regexp for SemVer 2.0 versions, from semver.org
+var RegexpSemVer = regexp.MustCompile(`^(0|[1-9]\d*)\.(0|[1-9]\d*)\.(0|[1-9]\d*)` +
+	`(?:-((?:0|[1-9]\d*|\d*[a-zA-Z-][0-9a-zA-Z-]*)(?:\.(?:0|[1-9]\d*|\d*[a-zA-Z-][0-9a-zA-Z-]*))*))?` +
+	`(?:\+([0-9a-zA-Z-]+(?:\.[0-9a-zA-Z-]+)*))?$`)
+
+// regexpIdentifier matches pre-release identifiers that are bump levels
+var regexpIdentifier = regexp.MustCompile(`^[a-zA-Z][0-9a-zA-Z-]*$`)
+
+// SemVerScheme is strict SemVer 2.0, with pre-release and build metadata
+type SemVerScheme struct{}
+
+type semver struct {
+	numbers    [3]int
+	prerelease []string
+}
+
+func parseSemVer(version string) (semver, error) {
+	match := RegexpSemVer.FindStringSubmatch(version)
+	if match == nil {
+		return semver{}, fmt.Errorf("'%s' is not a valid SemVer 2.0 version", version)
+	}
+	var v semver
+	for i := 0; i < 3; i++ {
+		v.numbers[i], _ = strconv.Atoi(match[i+1])
+	}
+	if match[4] != "" {
+		v.prerelease = strings.Split(match[4], ".")
+	}
+	return v, nil
+}
+
+func (v semver) String() string {
+	version := fmt.Sprintf("%d.%d.%d", v.numbers[0], v.numbers[1], v.numbers[2])
+	if len(v.prerelease) > 0 {
+		version += "-" + strings.Join(v.prerelease, ".")
+	}
+	return version
+}
+
+// Check returns an error if version is not SemVer 2.0
+func (s SemVerScheme) Check(version string) error {
+	_, err := parseSemVer(version)
+	return err
+}
+
+// Compare compares versions following SemVer 2.0 precedence rules, ignoring
+// build metadata
+func (s SemVerScheme) Compare(a, b string) int {
+	va, _ := parseSemVer(a)
+	vb, _ := parseSemVer(b)
+	for i := 0; i < 3; i++ {
+		if va.numbers[i] != vb.numbers[i] {
+			return compareInts(va.numbers[i], vb.numbers[i])
+		}
+	}
+	// a version without pre-release has higher precedence
+	if len(va.prerelease) == 0 || len(vb.prerelease) == 0 {
+		return compareInts(len(vb.prerelease), len(va.prerelease))
+	}
+	for i := 0; i < len(va.prerelease) && i < len(vb.prerelease); i++ {
+		if c := compareIdentifiers(va.prerelease[i], vb.prerelease[i]); c != 0 {
+			return c
+		}
+	}
+	return compareInts(len(va.prerelease), len(vb.prerelease))
+}
+
+// compareIdentifiers compares pre-release identifiers: numeric identifiers
+// compare numerically and are lower than alphanumeric ones
+func compareIdentifiers(a, b string) int {
+	na, errA := strconv.Atoi(a)
+	nb, errB := strconv.Atoi(b)
+	switch {
+	case errA == nil && errB == nil:
+		return compareInts(na, nb)
+	case errA == nil:
+		return -1
+	case errB == nil:
+		return 1
+	}
+	return strings.Compare(a, b)
+}
+
+// Bump bumps version. Bumping to a pre-release identifier such as rc starts
+// a pre-release of next patch version (as in 1.2.4-rc.1) or increments its
+// number if version is already at that pre-release. Bumping a pre-release to
+// a lower one also goes to next patch version.
+func (s SemVerScheme) Bump(version, level string) (string, error) {
+	v, err := parseSemVer(version)
+	if err != nil {
+		return "", err
+	}
+	for index, name := range BumpLevels {
+		if level == name {
+			next := semver{numbers: v.numbers}
+			next.numbers[index]++
+			for i := index + 1; i < 3; i++ {
+				next.numbers[i] = 0
+			}
+			return next.String(), nil
+		}
+	}
+	if !regexpIdentifier.MatchString(level) {
+		return "", fmt.Errorf("unknown bump level '%s'", level)
+	}
+	if len(v.prerelease) > 0 && v.prerelease[0] == level {
+		next := semver{numbers: v.numbers, prerelease: []string{level, "1"}}
+		if len(v.prerelease) > 1 {
+			if n, err := strconv.Atoi(v.prerelease[len(v.prerelease)-1]); err == nil {
+				next.prerelease = append([]string{}, v.prerelease...)
+				next.prerelease[len(next.prerelease)-1] = strconv.Itoa(n + 1)
+			}
+		}
+		return next.String(), nil
+	}
+	next := semver{numbers: v.numbers, prerelease: []string{level, "1"}}
+	// version must not go backwards to a lower pre-release
+	if len(v.prerelease) == 0 || s.Compare(next.String(), version) <= 0 {
+		next.numbers[2]++
+	}
+	return next.String(), nil
+}
+
+// Prerelease tells if version has a pre-release part
+func (s SemVerScheme) Prerelease(version string) bool {
+	v, _ := parseSemVer(version)
+	return len(v.prerelease) > 0
+}
+
+// Final returns version without pre-release part and build metadata
+func (s SemVerScheme) Final(version string) string {
+	v, _ := parseSemVer(version)
+	return semver{numbers: v.numbers}.String()
+}
+
+// Snapshot tells if first pre-release identifier is SNAPSHOT
+func (s SemVerScheme) Snapshot(version string) bool {
+	v, _ := parseSemVer(version)
+	return len(v.prerelease) > 0 && strings.EqualFold(v.prerelease[0], "SNAPSHOT")
+}
+
+// RegexpCalVer is the regexp for CalVer versions YYYY.MM.MICRO
+var RegexpCalVer = regexp.MustCompile(`^(\d{4})\.(0?[1-9]|1[0-2])\.(\d+)$`)
+
+// CalVerScheme is calendar versioning YYYY.MM.MICRO, such as 2024.05.1
+type CalVerScheme struct{}
+
+// Check returns an error if version is not YYYY.MM.MICRO
+func (s CalVerScheme) Check(version string) error {
+	if !RegexpCalVer.MatchString(version) {
+		return fmt.Errorf("'%s' is not a valid CalVer version (YYYY.MM.MICRO)", version)
+	}
+	return nil
+}
+
+// Compare compares versions numerically
+func (s CalVerScheme) Compare(a, b string) int {
+	return LooseScheme{}.Compare(a, b)
+}
+
+// Bump returns version for current month, with micro number incremented if
+// version is of current month, whatever the level
+func (s CalVerScheme) Bump(version, level string) (string, error) {
+	match := RegexpCalVer.FindStringSubmatch(version)
+	if match == nil {
+		return "", s.Check(version)
+	}
+	month := "1"
+	if len(match[2]) == 2 {
+		month = "01"
+	}
+	period := now().Local().Format("2006." + month)
+	if period == match[1]+"."+match[2] {
+		micro, _ := strconv.Atoi(match[3])
+		return fmt.Sprintf("%s.%d", period, micro+1), nil
+	}
+	return period + ".0", nil
+}
+
+// Prerelease is always false for CalVer
+func (s CalVerScheme) Prerelease(version string) bool {
+	return false
+}
+
+// Final returns version as is
+func (s CalVerScheme) Final(version string) string {
+	return version
+}
+
+// Snapshot is always false for CalVer
+func (s CalVerScheme) Snapshot(version string) bool {
+	return false
+}
+
+// RegexScheme is a custom scheme where versions must match a regexp. Versions
+// are compared by their numbers and bumps increment the first (major), second
+// (minor) or third (patch) number.
+type RegexScheme struct {
+	Regexp *regexp.Regexp
+}
+
+// regexpNumber matches numbers in versions of regex scheme
+var regexpNumber = regexp.MustCompile(`\d+`)
+
+// Check returns an error if version doesn't match regexp
+func (s RegexScheme) Check(version string) error {
+	if !s.Regexp.MatchString(version) {
+		return fmt.Errorf("'%s' doesn't match version pattern '%s'", version, s.Regexp)
+	}
+	return nil
+}
+
+// Compare compares versions by their numbers, then as strings
+func (s RegexScheme) Compare(a, b string) int {
+	na := regexpNumber.FindAllString(a, -1)
+	nb := regexpNumber.FindAllString(b, -1)
+	for i := 0; i < len(na) && i < len(nb); i++ {
+		if c := compareIdentifiers(na[i], nb[i]); c != 0 {
+			return c
+		}
+	}
+	if len(na) != len(nb) {
+		return compareInts(len(na), len(nb))
+	}
+	return strings.Compare(a, b)
+}
+
+// Bump increments the number for level and resets following numbers
+func (s RegexScheme) Bump(version, level string) (string, error) {
+	index := -1
+	for i, name := range BumpLevels {
+		if level == name {
+			index = i
+		}
+	}
+	if index < 0 {
+		return "", fmt.Errorf("unknown bump level '%s'", level)
+	}
+	positions := regexpNumber.FindAllStringIndex(version, -1)
+	if index >= len(positions) {
+		return "", fmt.Errorf("version '%s' has no %s number", version, level)
+	}
+	var next strings.Builder
+	last := 0
+	for i, position := range positions {
+		next.WriteString(version[last:position[0]])
+		n, _ := strconv.Atoi(version[position[0]:position[1]])
+		if i == index {
+			n++
+		} else if i > index {
+			n = 0
+		}
+		next.WriteString(strconv.Itoa(n))
+		last = position[1]
+	}
+	next.WriteString(version[last:])
+	bumped := next.String()
+	if err := s.Check(bumped); err != nil {
+		return "", err
+	}
+	return bumped, nil
+}
+
+// Prerelease is always false for regex scheme
+func (s RegexScheme) Prerelease(version string) bool {
+	return false
+}
+
+// Final returns version as is
+func (s RegexScheme) Final(version string) string {
+	return version
+}
+
+// Snapshot tells if version contains SNAPSHOT, whatever the case
+func (s RegexScheme) Snapshot(version string) bool {
+	return strings.Contains(strings.ToUpper(version), "SNAPSHOT")
+}
+
+// PrefixScheme wraps a scheme for versions with a prefix, such as v1.2.3
+type PrefixScheme struct {
+	Prefix string
+	Scheme VersionScheme
+}
+
+// Check returns an error if version has no prefix or is not valid
+func (s PrefixScheme) Check(version string) error {
+	if !strings.HasPrefix(version, s.Prefix) {
+		return fmt.Errorf("'%s' doesn't start with prefix '%s'", version, s.Prefix)
+	}
+	return s.Scheme.Check(strings.TrimPrefix(version, s.Prefix))
+}
+
+// Compare compares versions without prefix
+func (s PrefixScheme) Compare(a, b string) int {
+	return s.Scheme.Compare(strings.TrimPrefix(a, s.Prefix), strings.TrimPrefix(b, s.Prefix))
+}
+
+// Bump bumps version without prefix
+func (s PrefixScheme) Bump(version, level string) (string, error) {
+	if err := s.Check(version); err != nil {
+		return "", err
+	}
+	next, err := s.Scheme.Bump(strings.TrimPrefix(version, s.Prefix), level)
+	if err != nil {
+		return "", err
+	}
+	return s.Prefix + next, nil
+}
+
+// Prerelease tells if version without prefix is a pre-release
+func (s PrefixScheme) Prerelease(version string) bool {
+	return s.Scheme.Prerelease(strings.TrimPrefix(version, s.Prefix))
+}
+
+// Final returns version without pre-release part, with prefix
+func (s PrefixScheme) Final(version string) string {
+	return s.Prefix + s.Scheme.Final(strings.TrimPrefix(version, s.Prefix))
+}
+
+// Snapshot tells if version without prefix is a snapshot
+func (s PrefixScheme) Snapshot(version string) bool {
+	return s.Scheme.Snapshot(strings.TrimPrefix(version, s.Prefix))
+}
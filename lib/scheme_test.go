@@ -0,0 +1,148 @@
+package lib
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSemVerScheme(t *testing.T) {
+	scheme := SemVerScheme{}
+	var ordered = []string{
+		"1.0.0-alpha", "1.0.0-alpha.1", "1.0.0-alpha.beta", "1.0.0-beta",
+		"1.0.0-beta.2", "1.0.0-beta.11", "1.0.0-rc.1", "1.0.0", "1.0.1+build.5", "2.0.0",
+	}
+	for i := range ordered {
+		for j := range ordered {
+			if scheme.Compare(ordered[i], ordered[j]) != compareInts(i, j) {
+				t.Errorf("Comparing %s with %s should give %d", ordered[i], ordered[j],
+					compareInts(i, j))
+			}
+		}
+	}
+	if scheme.Compare("1.0.0+a", "1.0.0+b") != 0 {
+		t.Errorf("Build metadata should be ignored in comparison")
+	}
+	for _, version := range []string{"1.2", "01.2.3", "1.2.3-", "1.2.3-01", "v1.2.3"} {
+		if scheme.Check(version) == nil {
+			t.Errorf("Version %s should not be valid", version)
+		}
+	}
+	checkBumps(t, scheme, [][3]string{
+		{"1.2.3", "minor", "1.3.0"},
+		{"1.2.3-rc.1+build", "major", "2.0.0"},
+		{"1.2.3", "rc", "1.2.4-rc.1"},
+		{"1.2.4-rc.1", "rc", "1.2.4-rc.2"},
+		{"1.2.4-beta.3", "rc", "1.2.4-rc.1"},
+		{"1.2.4-rc.1", "alpha", "1.2.5-alpha.1"},
+	})
+}
+
+func TestCalVerScheme(t *testing.T) {
+	defer func() { now = time.Now }()
+	now = func() time.Time { return time.Date(2024, 5, 10, 12, 0, 0, 0, time.Local) }
+	scheme := CalVerScheme{}
+	if scheme.Compare("2024.05.2", "2024.05.10") != -1 {
+		t.Errorf("Version 2024.05.2 should be lower than 2024.05.10")
+	}
+	if scheme.Check("2024.13.1") == nil {
+		t.Errorf("Version 2024.13.1 should not be valid")
+	}
+	checkBumps(t, scheme, [][3]string{
+		{"2024.05.1", "patch", "2024.05.2"},
+		{"2024.04.3", "patch", "2024.05.0"},
+		{"2024.4.3", "minor", "2024.5.0"},
+	})
+}
+
+func TestRegexScheme(t *testing.T) {
+	scheme, err := NewVersionScheme("regex", `^r\d+\.\d+$`, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if scheme.Check("r1.2") != nil || scheme.Check("1.2") == nil {
+		t.Errorf("Bad check for regex scheme")
+	}
+	if scheme.Compare("r1.10", "r1.9") != 1 {
+		t.Errorf("Version r1.10 should be greater than r1.9")
+	}
+	checkBumps(t, scheme, [][3]string{
+		{"r1.2", "major", "r2.0"},
+		{"r1.2", "minor", "r1.3"},
+	})
+	if _, err := scheme.Bump("r1.2", "patch"); err == nil {
+		t.Errorf("Bumping patch of r1.2 should fail")
+	}
+}
+
+func TestPrefixScheme(t *testing.T) {
+	scheme, err := NewVersionScheme("semver", "", "v")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if scheme.Check("v1.2.3") != nil || scheme.Check("1.2.3") == nil {
+		t.Errorf("Bad check for prefixed scheme")
+	}
+	if scheme.Compare("v1.2.3", "v1.10.0") != -1 {
+		t.Errorf("Version v1.2.3 should be lower than v1.10.0")
+	}
+	checkBumps(t, scheme, [][3]string{{"v1.2.3", "patch", "v1.2.4"}})
+	if _, err := NewVersionScheme("foo", "", ""); err == nil {
+		t.Errorf("Scheme foo should be unknown")
+	}
+}
+
+func checkBumps(t *testing.T, scheme VersionScheme, bumps [][3]string) {
+	t.Helper()
+	for _, bump := range bumps {
+		next, err := scheme.Bump(bump[0], bump[1])
+		if err != nil {
+			t.Errorf("Bumping %s to %s failed: %v", bump[0], bump[1], err)
+		} else if next != bump[2] {
+			t.Errorf("Bumping %s to %s should give %s, got %s", bump[0], bump[1], bump[2], next)
+		}
+	}
+}
+
+func TestFinal(t *testing.T) {
+	prefixed, _ := NewVersionScheme("semver", "", "v")
+	var tests = []struct {
+		scheme  VersionScheme
+		version string
+		final   string
+	}{
+		{LooseScheme{}, "1.2.3-RC-2", "1.2.3"},
+		{LooseScheme{}, "1.2-SNAPSHOT", "1.2"},
+		{SemVerScheme{}, "1.2.3-rc.1+build", "1.2.3"},
+		{prefixed, "v1.2.3-beta.1", "v1.2.3"},
+		{CalVerScheme{}, "2024.05.1", "2024.05.1"},
+	}
+	for _, test := range tests {
+		if final := test.scheme.Final(test.version); final != test.final {
+			t.Errorf("Final version of %s should be %s, got %s", test.version, test.final, final)
+		}
+	}
+}
+
+func TestSnapshot(t *testing.T) {
+	prefixed, _ := NewVersionScheme("semver", "", "v")
+	var tests = []struct {
+		scheme   VersionScheme
+		version  string
+		snapshot bool
+	}{
+		{LooseScheme{}, "1.2.0-SNAPSHOT", true},
+		{LooseScheme{}, "1.2.0-SNAPSHOT-1", true},
+		{LooseScheme{}, "1.2.0-snapshot", true},
+		{LooseScheme{}, "1.2.0-RC-1", false},
+		{LooseScheme{}, "1.2.0", false},
+		{SemVerScheme{}, "1.2.0-SNAPSHOT.1", true},
+		{SemVerScheme{}, "1.2.0-rc.1", false},
+		{prefixed, "v1.2.0-SNAPSHOT", true},
+		{CalVerScheme{}, "2024.05.1", false},
+	}
+	for _, test := range tests {
+		if snapshot := test.scheme.Snapshot(test.version); snapshot != test.snapshot {
+			t.Errorf("Snapshot of %s should be %v, got %v", test.version, test.snapshot, snapshot)
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package lib
+
+import (
+	"fmt"
+	"time"
+)
+
+// Selection selects releases of a changelog. Empty fields don't filter.
+type Selection struct {
+	// Version selects the release with this version
+	Version string
+	// Since selects releases with a version greater than this one
+	Since string
+	// Until selects releases with a version lower or equal to this one
+	Until string
+	// FromDate selects releases dated from this date
+	FromDate string
+	// LatestStable skips unreleased changes and pre-releases on top of changelog
+	LatestStable bool
+}
+
+// Empty tells if selection doesn't filter releases
+func (s Selection) Empty() bool {
+	return s == Selection{}
+}
+
+// Select returns releases of changelog matching selection, in changelog order
+func (s Selection) Select(changelog Changelog) (Changelog, error) {
+	for _, version := range []string{s.Since, s.Until} {
+		if version != "" {
+			if err := Scheme.Check(version); err != nil {
+				return nil, usageError("bad version in selection: %v", err)
+			}
+		}
+	}
+	var fromDate time.Time
+	if s.FromDate != "" {
+		var err error
+		if fromDate, err = time.Parse(DateFormat, s.FromDate); err != nil {
+			return nil, usageError("bad date '%s' in selection", s.FromDate)
+		}
+	}
+	if s.LatestStable {
+		for len(changelog) > 0 && (changelog[0].Unreleased() ||
+			Scheme.Check(changelog[0].Version) == nil && Scheme.Prerelease(changelog[0].Version)) {
+			changelog = changelog[1:]
+		}
+	}
+	var selected Changelog
+	for _, release := range changelog {
+		valid := Scheme.Check(release.Version) == nil
+		if s.Version != "" && release.Version != s.Version &&
+			!(valid && Scheme.Check(s.Version) == nil && Scheme.Compare(release.Version, s.Version) == 0) {
+			continue
+		}
+		if s.Since != "" && (!valid || Scheme.Compare(release.Version, s.Since) <= 0) {
+			continue
+		}
+		if s.Until != "" && (!valid || Scheme.Compare(release.Version, s.Until) > 0) {
+			continue
+		}
+		if s.FromDate != "" {
+			date, err := time.Parse(DateFormat, release.Date)
+			if err != nil || date.Before(fromDate) {
+				continue
+			}
+		}
+		selected = append(selected, release)
+	}
+	if len(selected) == 0 {
+		return nil, fmt.Errorf("no release selected")
+	}
+	return selected, nil
+}
@@ -0,0 +1,82 @@
+package lib
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSelect(t *testing.T) {
+	changelog := Changelog{
+		{Version: "2.0.0-SNAPSHOT", Date: "2015-04-10"},
+		{Version: "1.10.0-RC", Date: "2015-04-01"},
+		{Version: "1.10.0", Date: "2015-03-30"},
+		{Version: "1.2.0", Date: "2015-02-01"},
+		{Version: "1.0.0", Date: "2015-01-01"},
+		{Version: "0.1.0", Date: "2014-12-01"},
+	}
+	var tests = []struct {
+		selection Selection
+		versions  string
+	}{
+		{Selection{Version: "1.2"}, "1.2.0"},
+		{Selection{Since: "1.0.0"}, "2.0.0-SNAPSHOT 1.10.0-RC 1.10.0 1.2.0"},
+		{Selection{Until: "1.2.0"}, "1.2.0 1.0.0 0.1.0"},
+		{Selection{Since: "1.0.0", Until: "1.10.0"}, "1.10.0-RC 1.10.0 1.2.0"},
+		{Selection{FromDate: "2015-03-30"}, "2.0.0-SNAPSHOT 1.10.0-RC 1.10.0"},
+		{Selection{LatestStable: true}, "1.10.0 1.2.0 1.0.0 0.1.0"},
+		{Selection{LatestStable: true, Since: "1.0.0"}, "1.10.0 1.2.0"},
+	}
+	for _, test := range tests {
+		selected, err := test.selection.Select(changelog)
+		if err != nil {
+			t.Errorf("Selecting %+v: %v", test.selection, err)
+			continue
+		}
+		var versions []string
+		for _, release := range selected {
+			versions = append(versions, release.Version)
+		}
+		if strings.Join(versions, " ") != test.versions {
+			t.Errorf("Selecting %+v gave %v", test.selection, versions)
+		}
+	}
+	for _, selection := range []Selection{
+		{Version: "3.0.0"}, {Since: "foo"}, {FromDate: "2015/01/01"},
+	} {
+		if _, err := selection.Select(changelog); err == nil {
+			t.Errorf("Selecting %+v should fail", selection)
+		}
+	}
+}
+
+func TestSelectReleases(t *testing.T) {
+	changelog := Changelog{{Version: "1.2.0"}, {Version: "1.1.0"}, {Version: "1.0.0"}}
+	context := Context{Changelog: changelog,
+		Options: &Options{Release: 1, Selection: Selection{Until: "1.0.0"}}}
+	if err := context.SelectReleases(); err != nil {
+		t.Fatal(err)
+	}
+	if context.Shift != 2 || len(context.Changelog) != 1 || context.Changelog[0].Version != "1.0.0" {
+		t.Errorf("Bad selection: shift %d, changelog %v", context.Shift, context.Changelog)
+	}
+}
+
+func TestSelectReleasesUnreleased(t *testing.T) {
+	changelog := Changelog{{Version: "unreleased"}, {Version: "1.1.0"}, {Version: "1.0.0"}}
+	context := Context{Changelog: changelog, Options: &Options{Release: 1}}
+	if err := context.SelectReleases(); err != nil {
+		t.Fatal(err)
+	}
+	if context.Shift != 2 || context.Changelog[0].Version != "1.0.0" {
+		t.Errorf("Shift should count releases after unreleased one: shift %d, changelog %v",
+			context.Shift, context.Changelog)
+	}
+	context = Context{Changelog: changelog, Options: &Options{Selection: Selection{LatestStable: true}}}
+	if err := context.SelectReleases(); err != nil {
+		t.Fatal(err)
+	}
+	if context.Shift != 1 || context.Changelog[0].Version != "1.1.0" {
+		t.Errorf("Latest stable release should skip unreleased one: shift %d, changelog %v",
+			context.Shift, context.Changelog)
+	}
+}
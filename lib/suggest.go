@@ -0,0 +1,69 @@
+package lib
+
+import (
+	"fmt"
+	"strings"
+)
+
+// SuggestLevel returns bump level for changes of releases and the reason:
+// major for removed entries or breaking changes, minor for added, changed or
+// deprecated entries, patch otherwise
+func SuggestLevel(releases ...Release) (string, string) {
+	level, reason := "patch", "only fixes"
+	for _, release := range releases {
+		if len(release.Section("removed")) > 0 {
+			return "major", "removed entries"
+		}
+		for _, section := range release.Sections {
+			for _, entry := range section.Entries {
+				if strings.HasPrefix(entry, BreakingPrefix) {
+					return "major", "breaking changes"
+				}
+			}
+		}
+		for _, section := range []string{"added", "changed", "deprecated"} {
+			if len(release.Section(section)) > 0 && level != "minor" {
+				level, reason = "minor", section+" entries"
+			}
+		}
+	}
+	return level, reason
+}
+
+// SuggestVersion returns version suggested for top release of changelog from
+// its changes since previous final release, and the reason of the bump.
+// Changes of pre-releases in between are also considered.
+func SuggestVersion(changelog Changelog) (string, string, error) {
+	previous := -1
+	for index := 1; index < len(changelog); index++ {
+		if !Scheme.Prerelease(changelog[index].Version) {
+			previous = index
+			break
+		}
+	}
+	if previous < 0 {
+		return "", "", fmt.Errorf("no previous release to compare with")
+	}
+	level, reason := SuggestLevel(changelog[:previous]...)
+	version, err := Scheme.Bump(changelog[previous].Version, level)
+	if err != nil {
+		return "", "", fmt.Errorf("bumping version %s: %v", changelog[previous].Version, err)
+	}
+	return version, fmt.Sprintf("%s bump for %s since %s", level, reason, changelog[previous].Version), nil
+}
+
+// suggest prints version suggested for top release and fails if its version
+// is too small
+func suggest(context *Context) error {
+	version, reason, err := SuggestVersion(context.Changelog)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(context.Out, version)
+	entered := context.Changelog[0].Version
+	if !isUnreleased(entered) && Scheme.Compare(Scheme.Final(entered), version) < 0 {
+		return fmt.Errorf("release version %s is too small, should be at least %s (%s)",
+			entered, version, reason)
+	}
+	return nil
+}
@@ -0,0 +1,48 @@
+package lib
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSuggestVersion(t *testing.T) {
+	var tests = []struct {
+		changelog Changelog
+		version   string
+	}{
+		{Changelog{{Version: "1.2.4", Sections: []Section{{"fixed", []string{"Bug"}}}}, {Version: "1.2.3"}}, "1.2.4"},
+		{Changelog{{Version: "1.3.0", Sections: []Section{{"added", []string{"Feature"}}, {"fixed", []string{"Bug"}}}}, {Version: "1.2.3"}}, "1.3.0"},
+		{Changelog{{Version: "1.3.0", Sections: []Section{{"deprecated", []string{"Option"}}}}, {Version: "1.2.3"}}, "1.3.0"},
+		{Changelog{{Version: "2.0.0", Sections: []Section{{"removed", []string{"Option"}}}}, {Version: "1.2.3"}}, "2.0.0"},
+		{Changelog{{Version: "2.0.0", Sections: []Section{{"changed", []string{BreakingPrefix + "new API"}}}}, {Version: "1.2.3"}}, "2.0.0"},
+		{Changelog{{Version: "1.3.0", Sections: []Section{{"fixed", []string{"Bug"}}}}, {Version: "1.3.0-RC-1", Sections: []Section{{"added", []string{"Feature"}}}},
+			{Version: "1.2.3"}}, "1.3.0"},
+	}
+	for _, test := range tests {
+		version, _, err := SuggestVersion(test.changelog)
+		if err != nil || version != test.version {
+			t.Errorf("Suggested version for %+v should be %s, got %s (%v)", test.changelog, test.version, version, err)
+		}
+	}
+	if _, _, err := SuggestVersion(Changelog{{Version: "1.0.0"}}); err == nil {
+		t.Errorf("Suggesting version without previous release should fail")
+	}
+}
+
+func TestSuggestMismatch(t *testing.T) {
+	var out strings.Builder
+	context := &Context{Out: &out, Changelog: Changelog{
+		{Version: "1.2.4-RC-1", Sections: []Section{{"added", []string{"Feature"}}}}, {Version: "1.2.3"}}}
+	err := suggest(context)
+	if err == nil || err.Error() != "release version 1.2.4-RC-1 is too small, should be at least 1.3.0 "+
+		"(minor bump for added entries since 1.2.3)" {
+		t.Errorf("Too small version should be reported, got %v", err)
+	}
+	if out.String() != "1.3.0\n" {
+		t.Errorf("Suggested version should be printed, got %q", out.String())
+	}
+	context.Changelog[0].Version = "1.3.0-RC-1"
+	if err := suggest(context); err != nil {
+		t.Errorf("Pre-release of suggested version should be accepted, got %v", err)
+	}
+}
@@ -0,0 +1,87 @@
+package lib
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Tag is a git tag of a release
+type Tag struct {
+	// Name is the name of tag, such as v1.2.3
+	Name string
+	// Version is the name of tag without prefix
+	Version string
+	// Date is the ISO date of tag
+	Date string
+}
+
+// ReadTags returns git tags of directory that are release versions with
+// configured prefix, sorted by name
+func ReadTags(dir string) ([]Tag, error) {
+	output, err := git(dir, "for-each-ref", "--format=%(refname:short) %(creatordate:short)", "refs/tags")
+	if err != nil {
+		return nil, err
+	}
+	var tags []Tag
+	for _, line := range strings.Split(strings.TrimSpace(output), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 2 || !strings.HasPrefix(fields[0], Repository.Tag) {
+			continue
+		}
+		version := strings.TrimPrefix(fields[0], Repository.Tag)
+		if Scheme.Check(version) != nil {
+			continue
+		}
+		tags = append(tags, Tag{Name: fields[0], Version: version, Date: fields[1]})
+	}
+	sort.Slice(tags, func(i, j int) bool { return tags[i].Name < tags[j].Name })
+	return tags, nil
+}
+
+// checkTags compares releases with tags: releases without tag (except a top
+// SNAPSHOT or unreleased release), tags without release and tag dates that differ from
+// release dates by more than configured tolerance
+func checkTags(changelog Changelog, tags []Tag) ValidationErrors {
+	var errors ValidationErrors
+	byVersion := make(map[string]Tag)
+	for _, tag := range tags {
+		byVersion[tag.Version] = tag
+	}
+	released := make(map[string]bool)
+	for index, release := range changelog {
+		released[release.Version] = true
+		report := func(field, format string, args ...interface{}) {
+			errors = append(errors, ValidationError{Release: index, Version: release.Version,
+				Field: field, Message: fmt.Sprintf(format, args...)})
+		}
+		tag, ok := byVersion[release.Version]
+		if !ok {
+			if !(index == 0 && (isSnapshot(release.Version) || release.Unreleased())) {
+				report("version", "Release has no tag %s", Repository.TagName(release.Version))
+			}
+			continue
+		}
+		date, err := time.Parse(DateFormat, release.Date)
+		if err != nil {
+			continue
+		}
+		tagged, _ := time.Parse(ISODateFormat, tag.Date)
+		days := int(tagged.Sub(date).Hours() / 24)
+		if days < 0 {
+			days = -days
+		}
+		if days > Repository.Tolerance {
+			report("date", "Tag %s date %s differs from release date %s by %d day(s)",
+				tag.Name, tagged.Format(DateFormat), release.Date, days)
+		}
+	}
+	for _, tag := range tags {
+		if !released[tag.Version] {
+			errors = append(errors, ValidationError{Release: -1,
+				Message: fmt.Sprintf("Tag %s has no release in changelog", tag.Name)})
+		}
+	}
+	return errors
+}
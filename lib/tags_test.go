@@ -0,0 +1,57 @@
+package lib
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestReadTags(t *testing.T) {
+	repository := Repository
+	defer func() { Repository = repository }()
+	Repository = RepositoryConfig{Tag: "v"}
+	dir := initRepository(t, "v1.0.0", "first", "second")
+	for _, tag := range []string{"v1.1.0", "latest", "1.2.0"} {
+		if _, err := git(dir, "tag", tag); err != nil {
+			t.Fatal(err)
+		}
+	}
+	tags, err := ReadTags(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	today := time.Now().Format(ISODateFormat)
+	expected := []Tag{{Name: "v1.0.0", Version: "1.0.0", Date: today}, {Name: "v1.1.0", Version: "1.1.0", Date: today}}
+	if !reflect.DeepEqual(tags, expected) {
+		t.Errorf("Bad release tags: %+v", tags)
+	}
+}
+
+func TestCheckTags(t *testing.T) {
+	repository := Repository
+	defer func() { Repository = repository }()
+	Repository = RepositoryConfig{Tag: "v", Tolerance: 1}
+	changelog := Changelog{
+		{Version: "1.3.0-SNAPSHOT", Date: "2024-04-01"},
+		{Version: "1.2.0", Date: "2024-03-01"},
+		{Version: "1.1.0", Date: "2024-02-01"},
+		{Version: "1.0.0", Date: "2024-01-01"},
+	}
+	tags := []Tag{
+		{Name: "v0.9.0", Version: "0.9.0", Date: "2023-12-01"},
+		{Name: "v1.0.0", Version: "1.0.0", Date: "2024-01-02"},
+		{Name: "v1.1.0", Version: "1.1.0", Date: "2024-02-05"},
+	}
+	var messages []string
+	for _, err := range checkTags(changelog, tags) {
+		messages = append(messages, err.Error())
+	}
+	expected := []string{
+		"release 1.2.0: Release has no tag v1.2.0",
+		"release 1.1.0: Tag v1.1.0 date 2024-02-05 differs from release date 2024-02-01 by 4 day(s)",
+		"Tag v0.9.0 has no release in changelog",
+	}
+	if !reflect.DeepEqual(messages, expected) {
+		t.Errorf("Bad tag errors: %q", messages)
+	}
+}
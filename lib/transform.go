@@ -2,10 +2,14 @@ package lib
 
 import (
 	"fmt"
+	htmltemplate "html/template"
+	"io"
 	"io/ioutil"
-	"os"
 	"path/filepath"
+	"sort"
+	"strings"
 	"text/template"
+	"time"
 )
 
 const (
@@ -24,69 +28,13 @@ const (
 <body>
 <h1>Changelog</h1>
 {{ range $release := .Changelog }}
-<h2>Release {{ .Version }} ({{ .Date }})</h2>
-<p>{{ .Summary }}</p>
-{{ if .Added }}
-<h3>Added</h3>
+{{ if .Unreleased }}<h2>Unreleased</h2>{{ else }}<h2>Release {{ .Version }} ({{ .Date }})</h2>{{ end }}
+<p>{{ markdown .Summary }}</p>
+{{ range .Sections }}
+<h3>{{ .Title }}</h3>
 <ul>
-{{ range $entry := .Added }}
-<li>{{ . }}</li>
-{{ end }}
-</ul>
-{{ end }}
-{{ if .Changed }}
-<h3>Changed</h3>
-<ul>
-{{ range $entry := .Changed }}
-<li>{{ . }}</li>
-{{ end }}
-</ul>
-{{ end }}
-{{ if .Deprecated }}
-<h3>Deprecated</h3>
-<ul>
-{{ range $entry := .Deprecated }}
-<li>{{ . }}</li>
-{{ end }}
-</ul>
-{{ end }}
-{{ if .Removed }}
-<h3>Removed</h3>
-<ul>
-{{ range $entry := .Removed }}
-<li>{{ . }}</li>
-{{ end }}
-</ul>
-{{ end }}
-{{ if .Fixed }}
-<h3>Fixed</h3>
-<ul>
-{{ range $entry := .Fixed }}
-<li>{{ . }}</li>
-{{ end }}
-</ul>
-{{ end }}
-{{ if .Security }}
-<h3>Security</h3>
-<ul>
-{{ range $entry := .Security }}
-<li>{{ . }}</li>
-{{ end }}
-</ul>
-{{ end }}
-{{ if .Rejected }}
-<h3>Rejected</h3>
-<ul>
-{{ range $entry := .Rejected }}
-<li>{{ . }}</li>
-{{ end }}
-</ul>
-{{ end }}
-{{ if .Notes }}
-<h3>Notes</h3>
-<ul>
-{{ range $entry := .Notes }}
-<li>{{ . }}</li>
+{{ range .Entries }}
+<li>{{ markdown . }}</li>
 {{ end }}
 </ul>
 {{ end }}
@@ -490,123 +438,188 @@ pre code, pre tt {
 	// MdTemplate is a markdown template
 	MdTemplate = `# Changelog
 
-{{ range $release := .Changelog }}## Release {{ .Version }} ({{ .Date }})
+{{ range $release := .Changelog }}{{ if .Unreleased }}## Unreleased{{ else }}## Release {{ .Version }} ({{ .Date }}){{ end }}
 
 {{ if .Summary }}{{ .Summary }}{{ end }}
 
-{{ if .Added }}### Added
-
-{{ range $entry := .Added }}- {{ . }}
-{{ end }}{{ end }}{{ if .Changed }}
-### Changed
-
-{{ range $entry := .Changed }}- {{ . }}
-{{ end }}{{ end }}{{ if .Deprecated }}
-### Deprecated
-
-{{ range $entry := .Deprecated }}- {{ . }}
-{{ end }}{{ end }}{{ if .Removed }}
-### Removed
-
-{{ range $entry := .Removed }}- {{ . }}
-{{ end }}{{ end }}{{ if .Fixed }}
-### Fixed
-
-{{ range $entry := .Fixed }}- {{ . }}
-{{ end }}{{ end }}{{ if .Security }}
-### Security
+{{ range $index, $section := .Sections }}{{ if $index }}
+{{ end }}### {{ .Title }}
 
-{{ range $entry := .Security }}- {{ . }}
-{{ end }}{{ end }}{{ if .Rejected }}
-### Rejected
-
-{{ range $entry := .Rejected }}- {{ . }}
-{{ end }}{{ end }}{{ if .Notes }}
-### Notes
-
-{{ range $entry := .Notes }}- {{ . }}
+{{ range .Entries }}- {{ . }}
 {{ end }}{{ end }}
 {{ end }}`
 
-	// MdTemplateRelease is a markdown template for a release
-	MdTemplateRelease = `{{ if .Summary }}{{ .Summary }}{{ end }}
-
-{{ if .Added }}# Added
-
-{{ range $entry := .Added }}- {{ . }}
-{{ end }}{{ end }}{{ if .Changed }}
-# Changed
+	// KeepAChangelogTemplate is a markdown template following Keep a Changelog
+	KeepAChangelogTemplate = `{{ define "sections" }}{{ if .Summary }}
+{{ .Summary }}
+{{ end }}{{ range .Sections }}
+### {{ .Title }}
 
-{{ range $entry := .Changed }}- {{ . }}
-{{ end }}{{ end }}{{ if .Deprecated }}
-# Deprecated
+{{ range .Entries }}- {{ . }}
+{{ end }}{{ end }}{{ end }}# Changelog
 
-{{ range $entry := .Deprecated }}- {{ . }}
-{{ end }}{{ end }}{{ if .Removed }}
-# Removed
+All notable changes to this project will be documented in this file.
 
-{{ range $entry := .Removed }}- {{ . }}
-{{ end }}{{ end }}{{ if .Fixed }}
-# Fixed
+The format is based on [Keep a Changelog](https://keepachangelog.com/en/1.1.0/).
 
-{{ range $entry := .Fixed }}- {{ . }}
-{{ end }}{{ end }}{{ if .Security }}
-# Security
+## [Unreleased]
+{{ with .Unreleased }}{{ template "sections" . }}{{ end }}{{ range .Changelog }}
+## [{{ .Version }}] - {{ .Date }}
+{{ template "sections" . }}{{ end }}{{ if .Links }}
+{{ range .Links }}[{{ .Name }}]: {{ .URL }}
+{{ end }}{{ end }}`
 
-{{ range $entry := .Security }}- {{ . }}
-{{ end }}{{ end }}{{ if .Rejected }}
-# Rejected
+	// MdTemplateRelease is a markdown template for a release
+	MdTemplateRelease = `{{ if .Summary }}{{ .Summary }}{{ end }}
 
-{{ range $entry := .Rejected }}- {{ . }}
-{{ end }}{{ end }}{{ if .Notes }}
-# Notes
+{{ range $index, $section := .Sections }}{{ if $index }}
+{{ end }}# {{ .Title }}
 
-{{ range $entry := .Notes }}- {{ . }}
+{{ range .Entries }}- {{ . }}
 {{ end }}{{ end }}`
 
 	// MdTemplateDescription is a markdown template for a release description
-	MdTemplateDescription = `{{ if .Added }}# Added
+	MdTemplateDescription = `{{ range $index, $section := .Sections }}{{ if $index }}
+{{ end }}# {{ .Title }}
 
-{{ range $entry := .Added }}- {{ . }}
-{{ end }}{{ end }}{{ if .Changed }}
-# Changed
+{{ range .Entries }}- {{ . }}
+{{ end }}{{ end }}`
+)
 
-{{ range $entry := .Changed }}- {{ . }}
-{{ end }}{{ end }}{{ if .Deprecated }}
-# Deprecated
+// Templates are templates used for transformations, by name. They can be
+// overridden in configuration file.
+var Templates = map[string]string{
+	"html":           HTMLTemplate,
+	"markdown":       MdTemplate,
+	"keepachangelog": KeepAChangelogTemplate,
+	"release":        MdTemplateRelease,
+	"description":    MdTemplateDescription,
+}
+
+// templateNames returns sorted names of templates
+func templateNames() []string {
+	var names []string
+	for name := range Templates {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
 
-{{ range $entry := .Deprecated }}- {{ . }}
-{{ end }}{{ end }}{{ if .Removed }}
-# Removed
+// Formats are the formats changelog can be transformed to
+var Formats = []string{"html", "markdown", "keepachangelog", "json", "yaml"}
 
-{{ range $entry := .Removed }}- {{ . }}
-{{ end }}{{ end }}{{ if .Fixed }}
-# Fixed
+// DefaultFormat is the format used when none is passed on command line
+var DefaultFormat = ""
 
-{{ range $entry := .Fixed }}- {{ . }}
-{{ end }}{{ end }}{{ if .Security }}
-# Security
+// isFormat tells if format is known
+func isFormat(format string) bool {
+	for _, f := range Formats {
+		if f == format {
+			return true
+		}
+	}
+	return false
+}
 
-{{ range $entry := .Security }}- {{ . }}
-{{ end }}{{ end }}{{ if .Rejected }}
-# Rejected
+// TemplateDataChangelog contains data for changelog template
+type TemplateDataChangelog struct {
+	Changelog Changelog
+	// Stylesheets are the stylesheets to include in HTML page
+	Stylesheets []htmltemplate.CSS
+	// Unreleased is the release of unreleased changes, if any
+	Unreleased *Release
+	// Links are link reference definitions to compare releases
+	Links []Link
+}
+
+// templateFuncs are helper functions available in templates
+var templateFuncs = map[string]interface{}{
+	// date formats a release date with a format such as DD/MM/YYYY
+	"date": func(format, date string) string {
+		parsed, err := time.Parse(DateFormat, date)
+		if err != nil {
+			return date
+		}
+		return parsed.Format(dateLayout(format))
+	},
+	// compare compares two versions, returning -1, 0 or 1
+	"compare": func(a, b string) int {
+		return Scheme.Compare(a, b)
+	},
+	// prerelease tells if version is a pre-release
+	"prerelease": func(version string) bool {
+		return Scheme.Check(version) == nil && Scheme.Prerelease(version)
+	},
+	// sections returns non empty sections of a release, in configured order
+	"sections": func(release Release) []Section {
+		return release.Sections
+	},
+	// markdown renders inline markdown of text in HTML
+	"markdown": func(text string) htmltemplate.HTML {
+		return htmltemplate.HTML(MarkdownToHTML(text))
+	},
+}
+
+// executeTemplate parses template source with helper functions and executes
+// it with data. HTML templates escape data in HTML.
+func executeTemplate(out io.Writer, name, source string, html bool, data interface{}) error {
+	if html {
+		t, err := htmltemplate.New(name).Funcs(templateFuncs).Parse(source)
+		if err != nil {
+			return fmt.Errorf("Error parsing template: %s", err)
+		}
+		if err := t.Execute(out, data); err != nil {
+			return fmt.Errorf("Error processing template: %s", err)
+		}
+		return nil
+	}
+	t, err := template.New(name).Funcs(templateFuncs).Parse(source)
+	if err != nil {
+		return fmt.Errorf("Error parsing template: %s", err)
+	}
+	if err := t.Execute(out, data); err != nil {
+		return fmt.Errorf("Error processing template: %s", err)
+	}
+	return nil
+}
 
-{{ range $entry := .Rejected }}- {{ . }}
-{{ end }}{{ end }}{{ if .Notes }}
-# Notes
+// isHTMLTemplate tells if template file produces HTML, from its extension
+// such as .html or .html.tmpl
+func isHTMLTemplate(file string) bool {
+	for _, extension := range []string{".tmpl", ".tpl"} {
+		file = strings.TrimSuffix(file, extension)
+	}
+	extension := strings.ToLower(filepath.Ext(file))
+	return extension == ".html" || extension == ".htm"
+}
 
-{{ range $entry := .Notes }}- {{ . }}
-{{ end }}{{ end }}`
-)
+// readTemplate returns source of template file, or builtin template with
+// given name if file is empty
+func readTemplate(file, name string) (string, error) {
+	if file == "" {
+		return Templates[name], nil
+	}
+	source, err := ioutil.ReadFile(filepath.Clean(file))
+	if err != nil {
+		return "", fmt.Errorf("reading template file '%s'", file)
+	}
+	return string(source), nil
+}
 
-// TemplateDataChangelog contains data for changelog template
-type TemplateDataChangelog struct {
-	Changelog   Changelog
-	Stylesheets []string
+// released returns changelog without unreleased release on top
+func released(changelog Changelog) Changelog {
+	if len(changelog) > 0 && changelog[0].Unreleased() {
+		return changelog[1:]
+	}
+	return changelog
 }
 
-func toHTML(changelog Changelog, args []string) error {
-	Stylesheets := make([]string, 0)
+// toHTML renders changelog in HTML with builtin template, or user template
+// file. Data is escaped in HTML and inline markdown of entries and summaries
+// is rendered.
+func toHTML(out io.Writer, changelog Changelog, args []string, file string) error {
+	Stylesheets := make([]htmltemplate.CSS, 0)
 	for _, file := range args {
 		var Stylesheet []byte
 		var err error
@@ -618,69 +631,139 @@ func toHTML(changelog Changelog, args []string) error {
 				return fmt.Errorf("Error loading Stylesheet %s: %s", file, err.Error())
 			}
 		}
-		Stylesheets = append(Stylesheets, string(Stylesheet))
+		Stylesheets = append(Stylesheets, htmltemplate.CSS(Stylesheet))
 	}
 	data := TemplateDataChangelog{
 		Stylesheets: Stylesheets,
 		Changelog:   changelog,
+		Links:       releaseLinks(released(changelog)),
 	}
-	t := template.Must(template.New("changelog").Parse(HTMLTemplate))
-	err := t.Execute(os.Stdout, data)
+	source, err := readTemplate(file, "html")
 	if err != nil {
-		return fmt.Errorf("Error processing template: %s", err)
+		return err
 	}
-	return nil
+	return executeTemplate(out, "changelog", source, true, data)
 }
 
-func toMarkdown(changelog Changelog) error {
+func toMarkdown(out io.Writer, changelog Changelog, file string) error {
 	data := TemplateDataChangelog{
 		Stylesheets: nil,
 		Changelog:   changelog,
+		Links:       releaseLinks(released(changelog)),
 	}
-	t := template.Must(template.New("changelog").Parse(MdTemplate))
-	err := t.Execute(os.Stdout, data)
+	source, err := readTemplate(file, "markdown")
 	if err != nil {
-		return fmt.Errorf("Error processing template: %s", err)
+		return err
 	}
-	return nil
+	return executeTemplate(out, "changelog", source, false, data)
 }
 
-func releaseToMarkdown(release Release) error {
-	t := template.Must(template.New("release").Parse(MdTemplateRelease))
-	err := t.Execute(os.Stdout, release)
+// toKeepAChangelog renders changelog in markdown following Keep a Changelog:
+// top unreleased or snapshot release goes in Unreleased section and dates are
+// in ISO format
+func toKeepAChangelog(out io.Writer, changelog Changelog, file string) error {
+	data := TemplateDataChangelog{Links: releaseLinks(changelog)}
+	for index, release := range changelog {
+		if date, err := time.Parse(DateFormat, release.Date); err == nil {
+			release.Date = date.Format(ISODateFormat)
+		}
+		if index == 0 && (isSnapshot(release.Version) || release.Unreleased()) {
+			unreleased := release
+			data.Unreleased = &unreleased
+			data.Links = releaseLinks(changelog[1:])
+			continue
+		}
+		data.Changelog = append(data.Changelog, release)
+	}
+	source, err := readTemplate(file, "keepachangelog")
 	if err != nil {
-		return fmt.Errorf("Error processing template: %s", err)
+		return err
 	}
-	return nil
+	return executeTemplate(out, "changelog", source, false, data)
 }
 
-func descriptionToMarkdown(release Release) error {
-	t := template.Must(template.New("description").Parse(MdTemplateDescription))
-	err := t.Execute(os.Stdout, release)
+// toTemplate renders changelog with template file, escaping data in HTML if
+// this is an HTML template
+func toTemplate(out io.Writer, changelog Changelog, file string) error {
+	data := TemplateDataChangelog{
+		Changelog: changelog,
+		Links:     releaseLinks(released(changelog)),
+	}
+	source, err := readTemplate(file, "")
 	if err != nil {
-		return fmt.Errorf("Error processing template: %s", err)
+		return err
 	}
-	return nil
+	return executeTemplate(out, filepath.Base(file), source, isHTMLTemplate(file), data)
+}
+
+func releaseToMarkdown(out io.Writer, release Release, file string) error {
+	source, err := readTemplate(file, "release")
+	if err != nil {
+		return err
+	}
+	return executeTemplate(out, "release", source, false, release)
 }
 
-func transform(changelog Changelog, args []string) error {
-	if err := checkChangelog(changelog); err != nil {
+func descriptionToMarkdown(out io.Writer, release Release, file string) error {
+	source, err := readTemplate(file, "description")
+	if err != nil {
+		return err
+	}
+	return executeTemplate(out, "description", source, false, release)
+}
+
+func transform(context *Context, args []string) error {
+	flags, args := commandFlags(args)
+	changelog := context.Changelog
+	if err := checkChangelog(changelog, context.Source, context.Shift); err != nil {
 		return fmt.Errorf("checking changelog: %v", err)
 	}
 	if len(args) < 1 {
-		return fmt.Errorf("you must pass format to transform to")
+		format := context.Options.Format
+		if format == "" {
+			format = DefaultFormat
+		}
+		if format == "" {
+			return usageError("you must pass format to transform to")
+		}
+		args = []string{format}
 	}
 	format := args[0]
+	file := flags["template"]
+	if format == "template" {
+		if len(args) != 2 || file != "" {
+			return usageError("you must pass template file")
+		}
+		file = args[1]
+	} else if file != "" && (format == "json" || format == "yaml") {
+		return usageError("option --template is not for %s format", format)
+	}
 	if format == "html" {
-		if err := toHTML(changelog, args[1:]); err != nil {
+		if err := toHTML(context.Out, changelog, args[1:], file); err != nil {
 			return fmt.Errorf("generating HTML: %v", err)
 		}
 	} else if format == "markdown" {
-		if err := toMarkdown(changelog); err != nil {
+		if err := toMarkdown(context.Out, changelog, file); err != nil {
+			return fmt.Errorf("generating markdown: %v", err)
+		}
+	} else if format == "keepachangelog" {
+		if err := toKeepAChangelog(context.Out, changelog, file); err != nil {
 			return fmt.Errorf("generating markdown: %v", err)
 		}
+	} else if format == "template" {
+		if err := toTemplate(context.Out, changelog, file); err != nil {
+			return fmt.Errorf("generating from template: %v", err)
+		}
+	} else if format == "json" {
+		if err := writeJSON(context.Out, changelog); err != nil {
+			return fmt.Errorf("generating JSON: %v", err)
+		}
+	} else if format == "yaml" {
+		if _, err := context.Out.Write(FormatChangelog(changelog)); err != nil {
+			return fmt.Errorf("generating YAML: %v", err)
+		}
 	} else {
-		return fmt.Errorf("unknown format %s", args[0])
+		return usageError("unknown format %s", args[0])
 	}
 	return nil
 }
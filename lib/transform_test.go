@@ -0,0 +1,137 @@
+package lib
+
+import (
+	"bytes"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestToKeepAChangelog(t *testing.T) {
+	repository := Repository
+	defer func() { Repository = repository }()
+	Repository = RepositoryConfig{Tag: "v",
+		Compare: "https://github.com/owner/repo/compare/{from}...{to}",
+		Release: "https://github.com/owner/repo/releases/tag/{tag}"}
+	changelog := Changelog{
+		{Version: "1.1.0-SNAPSHOT", Date: "2024-03-01", Sections: []Section{{"added", []string{"New feature"}}}},
+		{Version: "1.0.0", Date: "2024-02-01", Summary: "Second release", Sections: []Section{{"fixed", []string{"Bug"}}}},
+		{Version: "0.1.0", Date: "2024-01-01", Sections: []Section{{"added", []string{"First feature"}}}},
+	}
+	expected := `# Changelog
+
+All notable changes to this project will be documented in this file.
+
+The format is based on [Keep a Changelog](https://keepachangelog.com/en/1.1.0/).
+
+## [Unreleased]
+
+### Added
+
+- New feature
+
+## [1.0.0] - 2024-02-01
+
+Second release
+
+### Fixed
+
+- Bug
+
+## [0.1.0] - 2024-01-01
+
+### Added
+
+- First feature
+
+[unreleased]: https://github.com/owner/repo/compare/v1.0.0...HEAD
+[1.0.0]: https://github.com/owner/repo/compare/v0.1.0...v1.0.0
+[0.1.0]: https://github.com/owner/repo/releases/tag/v0.1.0
+`
+	var out bytes.Buffer
+	if err := toKeepAChangelog(&out, changelog, ""); err != nil {
+		t.Fatal(err)
+	}
+	if out.String() != expected {
+		t.Errorf("Bad Keep a Changelog output:\n%s", out.String())
+	}
+	imported, unmapped := ImportMarkdown(out.Bytes())
+	if len(imported) != 3 || imported[1].Summary != "Second release" || len(unmapped) != 2 {
+		t.Errorf("Output should be imported back: %+v %v", imported, unmapped)
+	}
+}
+
+func TestToTemplate(t *testing.T) {
+	changelog := Changelog{
+		{Version: "1.1.0-RC-1", Date: "2024-02-01", Sections: []Section{{"added", []string{"Use `x`"}}, {"fixed", []string{"Fix <b>"}}}},
+		{Version: "1.0.0", Date: "2024-01-01"},
+	}
+	source := `{{ range .Changelog }}{{ .Version }} {{ date "DD/MM/YYYY" .Date }} {{ prerelease .Version }} ` +
+		`{{ compare .Version "1.0.0" }}{{ range sections . }} {{ .Title }}:{{ range .Entries }} {{ markdown . }}{{ end }}` +
+		`{{ end }}
+{{ end }}`
+	dir := t.TempDir()
+	var tests = map[string]string{
+		"changelog.txt": "1.1.0-RC-1 01/02/2024 true 1 Added: Use <code>x</code> Fixed: Fix &lt;b&gt;\n" +
+			"1.0.0 01/01/2024 false 0\n",
+		"changelog.html.tmpl": "1.1.0-RC-1 01/02/2024 true 1 Added: Use <code>x</code> Fixed: Fix &lt;b&gt;\n" +
+			"1.0.0 01/01/2024 false 0\n",
+	}
+	for name, expected := range tests {
+		file := filepath.Join(dir, name)
+		if err := ioutil.WriteFile(file, []byte(source), 0644); err != nil {
+			t.Fatal(err)
+		}
+		var out bytes.Buffer
+		if err := toTemplate(&out, changelog, file); err != nil {
+			t.Fatal(err)
+		}
+		if out.String() != expected {
+			t.Errorf("Bad output of template %s:\n%s", name, out.String())
+		}
+	}
+	file := filepath.Join(dir, "raw.html")
+	if err := ioutil.WriteFile(file, []byte(`{{ range .Changelog }}{{ .Section "fixed" }}{{ end }}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	var out bytes.Buffer
+	if err := toTemplate(&out, changelog[:1], file); err != nil || out.String() != "[Fix &lt;b&gt;]" {
+		t.Errorf("HTML template should escape data, got %q (%v)", out.String(), err)
+	}
+}
+
+func TestToHTMLEscaping(t *testing.T) {
+	changelog := Changelog{{
+		Version: "1.0.0<script>alert(1)</script>",
+		Date:    "2024-01-01",
+		Summary: "Summary <img src=x onerror=alert(1)> with *emphasis*",
+		Sections: []Section{{"fixed", []string{
+			"Fixed <script>alert(1)</script> injection",
+			"Use `<b>` and [docs](https://example.com/?a=1&b=2)",
+			"[click](javascript:alert(1)) and [quote](http://x\"onmouseover=\"alert(1))",
+			"</li></ul><iframe src=x>",
+		}}},
+	}}
+	var out bytes.Buffer
+	if err := toHTML(&out, changelog, nil, ""); err != nil {
+		t.Fatal(err)
+	}
+	html := out.String()
+	for _, injected := range []string{"<script", "<img", "<iframe", "</li></ul>", `href="javascript:`, `"onmouseover=`} {
+		if strings.Contains(html, injected) {
+			t.Errorf("HTML output should not contain %q:\n%s", injected, html)
+		}
+	}
+	for _, rendered := range []string{
+		"<h2>Release 1.0.0&lt;script&gt;alert(1)&lt;/script&gt; (2024-01-01)</h2>",
+		"<p>Summary &lt;img src=x onerror=alert(1)&gt; with <em>emphasis</em></p>",
+		"<li>Fixed &lt;script&gt;alert(1)&lt;/script&gt; injection</li>",
+		`<li>Use <code>&lt;b&gt;</code> and <a href="https://example.com/?a=1&amp;b=2">docs</a></li>`,
+		"<li>[click](javascript:alert(1)) and",
+	} {
+		if !strings.Contains(html, rendered) {
+			t.Errorf("HTML output should contain %q:\n%s", rendered, html)
+		}
+	}
+}
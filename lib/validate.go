@@ -0,0 +1,291 @@
+package lib
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ValidationError is an error found while validating a changelog. Release
+// is the index of the release in changelog, -1 if error is not about a
+// release. Line and Column are positions in source, 0 if unknown.
+type ValidationError struct {
+	Release int    `json:"release"`
+	Version string `json:"version,omitempty"`
+	Field   string `json:"field,omitempty"`
+	Line    int    `json:"line,omitempty"`
+	Column  int    `json:"column,omitempty"`
+	Message string `json:"message"`
+}
+
+// Error returns error message with its position
+func (e ValidationError) Error() string {
+	var parts []string
+	if e.Line > 0 && e.Column > 0 {
+		parts = append(parts, fmt.Sprintf("line %d, column %d", e.Line, e.Column))
+	} else if e.Line > 0 {
+		parts = append(parts, fmt.Sprintf("line %d", e.Line))
+	}
+	if e.Version != "" {
+		parts = append(parts, fmt.Sprintf("release %s", e.Version))
+	} else if e.Release >= 0 {
+		parts = append(parts, fmt.Sprintf("release #%d", e.Release+1))
+	}
+	parts = append(parts, e.Message)
+	return strings.Join(parts, ": ")
+}
+
+// ValidationErrors is a list of validation errors
+type ValidationErrors []ValidationError
+
+// Error returns error messages, one per line
+func (e ValidationErrors) Error() string {
+	messages := make([]string, len(e))
+	for i, err := range e {
+		messages[i] = err.Error()
+	}
+	return strings.Join(messages, "\n")
+}
+
+// Validate checks changelog source strictly and returns all errors found:
+// unknown and duplicate keys, fields that are not strings, sections that are
+// not lists of strings and releases that don't pass release checks. Errors
+// are sorted by release and position in source.
+func Validate(source []byte) ValidationErrors {
+	sequence, errors := validateStructure(source)
+	if sequence == nil {
+		if errors == nil {
+			return checkReleases(nil)
+		}
+		return errors
+	}
+	// release checks run on a changelog built despite structure errors
+	changelog, skipped := lenientChangelog(sequence)
+	for _, e := range checkReleases(changelog) {
+		if skipped[fieldKey{e.Release, e.Field}] || skipped[fieldKey{e.Release, ""}] {
+			continue
+		}
+		e.Line, e.Column = locate(sequence, e.Release, e.Field)
+		errors = append(errors, e)
+	}
+	sort.SliceStable(errors, func(i, j int) bool {
+		if errors[i].Release != errors[j].Release {
+			return errors[i].Release < errors[j].Release
+		}
+		return errors[i].Line < errors[j].Line
+	})
+	return errors
+}
+
+// validateStructure checks structure of changelog source: unknown and
+// duplicate keys, fields that are not strings and sections that are not lists
+// of strings. Returns the sequence of releases, nil if changelog is empty or
+// can't be parsed.
+func validateStructure(source []byte) (*yaml.Node, ValidationErrors) {
+	var root yaml.Node
+	if err := yaml.Unmarshal(source, &root); err != nil {
+		return nil, ValidationErrors{{Release: -1, Message: err.Error()}}
+	}
+	if len(root.Content) == 0 {
+		return nil, nil
+	}
+	sequence := root.Content[0]
+	if sequence.Kind != yaml.SequenceNode {
+		return nil, ValidationErrors{{Release: -1, Line: sequence.Line, Column: sequence.Column,
+			Message: "changelog must be a list of releases"}}
+	}
+	var errors ValidationErrors
+	for index, node := range sequence.Content {
+		errors = append(errors, validateRelease(index, node)...)
+	}
+	return sequence, errors
+}
+
+// fieldKey identifies a field of release at given index, empty field
+// standing for the whole release
+type fieldKey struct {
+	release int
+	field   string
+}
+
+// lenientChangelog builds a changelog from sequence of releases, skipping
+// releases that are not maps, fields that are not strings, sections that are
+// not lists and entries that are not strings. The first value of a duplicate
+// key is kept. Returns skipped releases and fields, which release checks
+// should ignore as they are already reported.
+func lenientChangelog(sequence *yaml.Node) (Changelog, map[fieldKey]bool) {
+	changelog := make(Changelog, len(sequence.Content))
+	skipped := make(map[fieldKey]bool)
+	for index, node := range sequence.Content {
+		if node.Kind != yaml.MappingNode {
+			skipped[fieldKey{index, ""}] = true
+			continue
+		}
+		release := &changelog[index]
+		seen := make(map[string]bool)
+		for i := 0; i < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+			if seen[key.Value] {
+				continue
+			}
+			seen[key.Value] = true
+			if fieldIndex(key.Value) >= 0 {
+				if value.Kind != yaml.ScalarNode {
+					skipped[fieldKey{index, key.Value}] = true
+				} else if value.Tag != "!!null" {
+					switch key.Value {
+					case "version":
+						release.Version = value.Value
+					case "date":
+						release.Date = value.Value
+					case "summary":
+						release.Summary = value.Value
+					}
+				}
+			} else if sectionIndex(key.Value) >= 0 && value.Kind == yaml.SequenceNode {
+				for _, entry := range value.Content {
+					if entry.Kind == yaml.ScalarNode {
+						release.Add(key.Value, entry.Value)
+					}
+				}
+			}
+		}
+	}
+	return changelog, skipped
+}
+
+// locate returns position of field value of release at given index. If field
+// is not found, this is the position of the release.
+func locate(sequence *yaml.Node, index int, field string) (int, int) {
+	if index < 0 || index >= len(sequence.Content) {
+		return 0, 0
+	}
+	node := sequence.Content[index]
+	if node.Kind == yaml.MappingNode {
+		for i := 0; i < len(node.Content); i += 2 {
+			if node.Content[i].Value == field {
+				return node.Content[i+1].Line, node.Content[i+1].Column
+			}
+		}
+	}
+	return node.Line, node.Column
+}
+
+// validateRelease checks release node at given index
+func validateRelease(index int, node *yaml.Node) ValidationErrors {
+	if node.Kind != yaml.MappingNode {
+		return ValidationErrors{{Release: index, Line: node.Line, Column: node.Column,
+			Message: "release must be a map"}}
+	}
+	version := ""
+	for i := 0; i < len(node.Content); i += 2 {
+		if node.Content[i].Value == "version" && node.Content[i+1].Kind == yaml.ScalarNode {
+			version = node.Content[i+1].Value
+		}
+	}
+	var errors ValidationErrors
+	report := func(node *yaml.Node, field, format string, args ...interface{}) {
+		errors = append(errors, ValidationError{Release: index, Version: version,
+			Field: field, Line: node.Line, Column: node.Column,
+			Message: fmt.Sprintf(format, args...)})
+	}
+	seen := make(map[string]int)
+	for i := 0; i < len(node.Content); i += 2 {
+		key, value := node.Content[i], node.Content[i+1]
+		if line, ok := seen[key.Value]; ok {
+			report(key, key.Value, "duplicate key '%s' (first defined line %d)", key.Value, line)
+			continue
+		}
+		seen[key.Value] = key.Line
+		if fieldIndex(key.Value) >= 0 {
+			if value.Kind != yaml.ScalarNode {
+				report(value, key.Value, "field '%s' must be a string", key.Value)
+			}
+		} else if sectionIndex(key.Value) >= 0 {
+			if value.Kind == yaml.ScalarNode && value.Tag == "!!null" {
+				continue
+			}
+			if value.Kind != yaml.SequenceNode {
+				report(value, key.Value, "section '%s' must be a list", key.Value)
+				continue
+			}
+			for _, entry := range value.Content {
+				if entry.Kind != yaml.ScalarNode {
+					report(entry, key.Value, "entry of section '%s' must be a string", key.Value)
+				}
+			}
+		} else {
+			report(key, key.Value, "unknown key '%s'", key.Value)
+		}
+	}
+	return errors
+}
+
+// validateTags checks changelog source against git tags in directory and
+// returns errors with their position
+func validateTags(source []byte, dir string) (ValidationErrors, error) {
+	changelog, err := ParseChangelog(source)
+	if err != nil {
+		return nil, err
+	}
+	tags, err := ReadTags(dir)
+	if err != nil {
+		return nil, fmt.Errorf("reading git tags: %v", err)
+	}
+	return locateErrors(checkTags(changelog, tags), source, 0), nil
+}
+
+// locateErrors sets position in source of errors about releases. Releases
+// of errors start at release shift of source.
+func locateErrors(errors ValidationErrors, source []byte, shift int) ValidationErrors {
+	sequence, _ := validateStructure(source)
+	for i := range errors {
+		if errors[i].Release >= 0 {
+			errors[i].Release += shift
+		}
+		if sequence != nil {
+			errors[i].Line, errors[i].Column = locate(sequence, errors[i].Release, errors[i].Field)
+		}
+	}
+	return errors
+}
+
+func check(context *Context, args []string) error {
+	if len(args) > 1 || (len(args) == 1 && args[0] != "tags") {
+		return usageError("unknown check argument %s", args[len(args)-1])
+	}
+	format := context.Options.Format
+	if format == "" {
+		format = "text"
+	}
+	var errors ValidationErrors
+	if len(args) == 1 {
+		var err error
+		if errors, err = validateTags(context.Source, context.gitDir()); err != nil {
+			return err
+		}
+	} else {
+		errors = Validate(context.Source)
+	}
+	switch format {
+	case "text":
+		if len(errors) > 0 {
+			return fmt.Errorf("validating changelog:\n%v", errors)
+		}
+	case "json":
+		if errors == nil {
+			errors = ValidationErrors{}
+		}
+		if err := writeJSON(context.Out, errors); err != nil {
+			return fmt.Errorf("encoding errors: %v", err)
+		}
+		if len(errors) > 0 {
+			return fmt.Errorf("changelog has %d error(s)", len(errors))
+		}
+	default:
+		return usageError("unknown format %s (must be text or json)", format)
+	}
+	return nil
+}
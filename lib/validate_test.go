@@ -0,0 +1,243 @@
+package lib
+
+import (
+	"io"
+	"io/ioutil"
+	"testing"
+	"time"
+)
+
+func TestValidate(t *testing.T) {
+	source, err := ioutil.ReadFile("../test/CHANGELOG.yml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	errors := Validate(source)
+	if len(errors) != 1 || errors[0].Error() != "line 13, column 3: release 1.0.0: unknown key 'foo'" {
+		t.Errorf("Bad validation errors: %v", errors)
+	}
+	source = []byte(`- version: 1.0.0
+  date:    2015-03-30
+  fixd:
+  - Typo.
+  added: Not a list.
+  security:
+  - First.
+  security:
+  - Second.
+
+- version: 0.1.0
+  date:    [2015, 03, 29]
+  securty:
+  - Typo.
+`)
+	expected := []string{
+		"line 3, column 3: release 1.0.0: unknown key 'fixd'",
+		"line 5, column 10: release 1.0.0: section 'added' must be a list",
+		"line 8, column 3: release 1.0.0: duplicate key 'security' (first defined line 6)",
+		"line 12, column 12: release 0.1.0: field 'date' must be a string",
+		"line 13, column 3: release 0.1.0: unknown key 'securty'",
+	}
+	errors = Validate(source)
+	if len(errors) != len(expected) {
+		t.Fatalf("Bad validation errors: %v", errors)
+	}
+	for i, err := range errors {
+		if err.Error() != expected[i] {
+			t.Errorf("Bad validation error %q, expected %q", err.Error(), expected[i])
+		}
+	}
+}
+
+func TestValidateReleases(t *testing.T) {
+	source := []byte(`- version: 1.0.0-FOO
+  date:    2015/03/30
+  foo:     Bar
+
+- summary: No version
+
+- version: 0.1.0
+  date:    2015-03-29
+`)
+	expected := ValidationErrors{
+		{Release: 0, Version: "1.0.0-FOO", Field: "version", Line: 1, Column: 12,
+			Message: "Release version '1.0.0-FOO' is not a valid semantic version number"},
+		{Release: 0, Version: "1.0.0-FOO", Field: "date", Line: 2, Column: 12,
+			Message: "Release date '2015/03/30' is not valid ISO format"},
+		{Release: 0, Version: "1.0.0-FOO", Field: "foo", Line: 3, Column: 3,
+			Message: "unknown key 'foo'"},
+		{Release: 1, Field: "version", Line: 5, Column: 3,
+			Message: "Release version is empty"},
+		{Release: 1, Field: "date", Line: 5, Column: 3,
+			Message: "Release date is empty"},
+	}
+	errors := Validate(source)
+	if len(errors) != len(expected) {
+		t.Fatalf("Bad validation errors: %v", errors)
+	}
+	for i, err := range errors {
+		if err != expected[i] {
+			t.Errorf("Bad validation error %#v, expected %#v", err, expected[i])
+		}
+	}
+}
+
+func TestValidateOrder(t *testing.T) {
+	source := []byte(`- version: 1.2.0
+  date:    2015-03-30
+
+- version: 1.10.0
+  date:    2015-03-29
+
+- version: 1.2
+  date:    2015-03-28
+
+- version: 1.0.0-RC
+  date:    2015-03-27
+
+- version: 1.0.0
+  date:    2015-03-26
+`)
+	expected := []string{
+		"line 4, column 12: release 1.10.0: Release version '1.10.0' should be lower than previous release version '1.2.0'",
+		"line 7, column 12: release 1.2: Release version '1.2' is a duplicate of '1.2.0'",
+		"line 13, column 12: release 1.0.0: Release version '1.0.0' should be lower than previous release version '1.0.0-RC'",
+	}
+	errors := Validate(source)
+	if len(errors) != len(expected) {
+		t.Fatalf("Bad validation errors: %v", errors)
+	}
+	for i, err := range errors {
+		if err.Error() != expected[i] {
+			t.Errorf("Bad validation error %q, expected %q", err.Error(), expected[i])
+		}
+	}
+}
+
+func TestValidateMixed(t *testing.T) {
+	defer func() { now = time.Now }()
+	now = func() time.Time { return time.Date(2015, 4, 1, 12, 0, 0, 0, time.Local) }
+	source := []byte(`- version: 1.2.0
+  date:    2099-01-01
+  added:   x
+
+- version: 1.10.0
+  date:    [2015, 03, 29]
+  fixed:
+  - Fix.
+  fixed:
+  - Again.
+
+- Not a release
+`)
+	expected := []string{
+		"line 2, column 12: release 1.2.0: Release date 2099-01-01 is in the future",
+		"line 3, column 12: release 1.2.0: section 'added' must be a list",
+		"line 5, column 12: release 1.10.0: Release version '1.10.0' should be lower than previous release version '1.2.0'",
+		"line 6, column 12: release 1.10.0: field 'date' must be a string",
+		"line 9, column 3: release 1.10.0: duplicate key 'fixed' (first defined line 7)",
+		"line 12, column 3: release #3: release must be a map",
+	}
+	errors := Validate(source)
+	if len(errors) != len(expected) {
+		t.Fatalf("Bad validation errors: %v", errors)
+	}
+	for i, err := range errors {
+		if err.Error() != expected[i] {
+			t.Errorf("Bad validation error %q, expected %q", err.Error(), expected[i])
+		}
+	}
+}
+
+func TestValidateDates(t *testing.T) {
+	defer func() { now = time.Now }()
+	now = func() time.Time { return time.Date(2015, 4, 1, 12, 0, 0, 0, time.Local) }
+	source := []byte(`- version: 2.0.0-SNAPSHOT
+  date:    2015-05-01
+
+- version: 1.2.0
+  date:    2015-04-02
+
+- version: 1.1.0
+  date:    2015-13-45
+
+- version: 1.0.0
+  date:    2015-03-20
+
+- version: 0.2.0
+  date:    2015-03-25
+
+- version: 0.1.0
+  date:    2015-03-25
+`)
+	expected := []string{
+		"line 5, column 12: release 1.2.0: Release date 2015-04-02 is in the future",
+		"line 8, column 12: release 1.1.0: Release date '2015-13-45' is not a valid calendar date",
+		"line 14, column 12: release 0.2.0: Release date 2015-03-25 is after date of next release 2015-03-20",
+	}
+	errors := Validate(source)
+	if len(errors) != len(expected) {
+		t.Fatalf("Bad validation errors: %v", errors)
+	}
+	for i, err := range errors {
+		if err.Error() != expected[i] {
+			t.Errorf("Bad validation error %q, expected %q", err.Error(), expected[i])
+		}
+	}
+	// snapshot with a counter, as given by a second 'new snapshot'
+	source = []byte("- version: 2.0.0-SNAPSHOT-1\n  date:    2015-05-01\n")
+	if errors := Validate(source); len(errors) != 0 {
+		t.Errorf("Top snapshot with counter may be dated in the future: %v", errors)
+	}
+}
+
+func TestValidateUnreleased(t *testing.T) {
+	source := []byte(`- version: unreleased
+  added:
+  - Feature
+
+- version: 1.0.0
+  date:    2015-03-30
+
+- version: Unreleased
+  date:    2015-03-29
+`)
+	expected := []string{
+		"line 8, column 12: release Unreleased: Only top release may be unreleased",
+		"line 9, column 12: release Unreleased: Unreleased release must not have a date",
+	}
+	errors := Validate(source)
+	if len(errors) != len(expected) {
+		t.Fatalf("Bad validation errors: %v", errors)
+	}
+	for i, err := range errors {
+		if err.Error() != expected[i] {
+			t.Errorf("Bad validation error %q, expected %q", err.Error(), expected[i])
+		}
+	}
+}
+
+func TestReleaseErrorPosition(t *testing.T) {
+	source := []byte(`- version: 1.1.0
+  date:    2015-03-31
+
+- version: 1.0.x
+  date:    2015-03-30
+`)
+	changelog, err := ParseChangelog(source)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "checking changelog: line 4, column 12: release 1.0.x: Release version '1.0.x' is not a valid semantic version number"
+	context := &Context{Options: &Options{}, Out: io.Discard, Source: source, Changelog: changelog}
+	if err := release(context, nil); err == nil || err.Error() != expected {
+		t.Errorf("Bad release error: %v", err)
+	}
+	context = &Context{Options: &Options{Release: 1}, Out: io.Discard, Source: source, Changelog: changelog}
+	if err := context.SelectReleases(); err != nil {
+		t.Fatal(err)
+	}
+	if err := transform(context, []string{"markdown"}); err == nil || err.Error() != expected {
+		t.Errorf("Bad transform error with shift: %v", err)
+	}
+}
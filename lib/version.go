@@ -0,0 +1,144 @@
+package lib
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Version is a parsed release version such as 1.2.3-RC-1
+type Version struct {
+	Numbers []int
+	Suffix  string
+	Counter int
+}
+
+// ParseVersion parses a version string
+func ParseVersion(version string) (Version, error) {
+	if !RegexpVersion.MatchString(version) {
+		return Version{}, fmt.Errorf("version '%s' is not a valid semantic version number", version)
+	}
+	var parsed Version
+	parts := strings.Split(version, "-")
+	for _, number := range strings.Split(parts[0], ".") {
+		n, err := strconv.Atoi(number)
+		if err != nil {
+			return Version{}, fmt.Errorf("version '%s' has a bad number '%s'", version, number)
+		}
+		parsed.Numbers = append(parsed.Numbers, n)
+	}
+	if len(parts) > 1 {
+		parsed.Suffix = parts[1]
+	}
+	if len(parts) > 2 {
+		parsed.Counter, _ = strconv.Atoi(parts[2])
+	}
+	return parsed, nil
+}
+
+// String returns the version as a string
+func (v Version) String() string {
+	numbers := make([]string, len(v.Numbers))
+	for i, n := range v.Numbers {
+		numbers[i] = strconv.Itoa(n)
+	}
+	version := strings.Join(numbers, ".")
+	if v.Suffix != "" {
+		version += "-" + v.Suffix
+		if v.Counter > 0 {
+			version += "-" + strconv.Itoa(v.Counter)
+		}
+	}
+	return version
+}
+
+// SuffixOrder lists version suffixes from lowest to highest precedence. A
+// version without suffix comes after all of them.
+var SuffixOrder = []string{"SNAPSHOT", "ALPHA", "BETA", "RC"}
+
+// suffixRank returns rank of version suffix for comparison
+func suffixRank(suffix string) int {
+	for i, s := range SuffixOrder {
+		if strings.EqualFold(s, suffix) {
+			return i
+		}
+	}
+	return len(SuffixOrder)
+}
+
+// Compare returns -1, 0 or 1 if version is lower, equal or greater than
+// other. Missing numbers count as 0, thus 1.2 equals 1.2.0.
+func (v Version) Compare(other Version) int {
+	for i := 0; i < len(v.Numbers) || i < len(other.Numbers); i++ {
+		var a, b int
+		if i < len(v.Numbers) {
+			a = v.Numbers[i]
+		}
+		if i < len(other.Numbers) {
+			b = other.Numbers[i]
+		}
+		if a != b {
+			return compareInts(a, b)
+		}
+	}
+	if a, b := suffixRank(v.Suffix), suffixRank(other.Suffix); a != b {
+		return compareInts(a, b)
+	}
+	return compareInts(v.Counter, other.Counter)
+}
+
+func compareInts(a, b int) int {
+	if a < b {
+		return -1
+	} else if a > b {
+		return 1
+	}
+	return 0
+}
+
+// BumpLevels are the levels for a version bump
+var BumpLevels = []string{"major", "minor", "patch"}
+
+// Bump returns next version for given level, which is major, minor, patch or
+// a suffix such as rc. Bumping to a suffix starts a pre-release of next patch
+// version, or increments the counter if version is already at that suffix.
+// Bumping a pre-release to a lower suffix also goes to next patch version.
+func (v Version) Bump(level string) (Version, error) {
+	level = strings.ToLower(level)
+	for index, name := range BumpLevels {
+		if level == name {
+			next := Version{Numbers: make([]int, len(v.Numbers))}
+			copy(next.Numbers, v.Numbers)
+			for len(next.Numbers) <= index {
+				next.Numbers = append(next.Numbers, 0)
+			}
+			next.Numbers[index]++
+			for i := index + 1; i < len(next.Numbers); i++ {
+				next.Numbers[i] = 0
+			}
+			return next, nil
+		}
+	}
+	for _, suffix := range strings.Split(RegexSuffixes, "|") {
+		if level == suffix {
+			if strings.EqualFold(v.Suffix, level) {
+				next := v
+				next.Counter++
+				return next, nil
+			}
+			next := v
+			// version must not go backwards to a lower suffix
+			if v.Suffix == "" || suffixRank(level) < suffixRank(v.Suffix) {
+				var err error
+				next, err = v.Bump("patch")
+				if err != nil {
+					return Version{}, err
+				}
+			}
+			next.Suffix = strings.ToUpper(level)
+			next.Counter = 0
+			return next, nil
+		}
+	}
+	return Version{}, fmt.Errorf("unknown bump level '%s'", level)
+}
@@ -0,0 +1,67 @@
+package lib
+
+import (
+	"testing"
+)
+
+func TestVersionBump(t *testing.T) {
+	var bumps = []struct {
+		version string
+		level   string
+		next    string
+	}{
+		{"1.2.3", "major", "2.0.0"},
+		{"1.2.3", "minor", "1.3.0"},
+		{"1.2.3", "patch", "1.2.4"},
+		{"1.2", "patch", "1.2.1"},
+		{"1", "minor", "1.1"},
+		{"1.2.3-SNAPSHOT", "minor", "1.3.0"},
+		{"1.2.3", "rc", "1.2.4-RC"},
+		{"1.2.3-RC", "rc", "1.2.3-RC-1"},
+		{"1.2.3-RC-1", "RC", "1.2.3-RC-2"},
+		{"1.2.3-beta", "rc", "1.2.3-RC"},
+		{"1.2.3-RC", "snapshot", "1.2.4-SNAPSHOT"},
+		{"1.2.3-RC-2", "alpha", "1.2.4-ALPHA"},
+	}
+	for _, bump := range bumps {
+		version, err := ParseVersion(bump.version)
+		if err != nil {
+			t.Fatalf("Version %s should be valid: %v", bump.version, err)
+		}
+		next, err := version.Bump(bump.level)
+		if err != nil {
+			t.Fatalf("Bumping %s to %s failed: %v", bump.version, bump.level, err)
+		}
+		if next.String() != bump.next {
+			t.Errorf("Bumping %s to %s should give %s, got %s", bump.version, bump.level,
+				bump.next, next.String())
+		}
+	}
+	version, _ := ParseVersion("1.2.3")
+	if _, err := version.Bump("foo"); err == nil {
+		t.Errorf("Bumping to level foo should fail")
+	}
+}
+
+func TestVersionCompare(t *testing.T) {
+	var ordered = []string{
+		"0.1", "0.9.9", "1.0.0-SNAPSHOT", "1.0.0-snapshot-1", "1.0.0-ALPHA",
+		"1.0.0-ALPHA-2", "1.0.0-ALPHA-10", "1.0.0-BETA", "1.0.0-RC", "1.0.0-RC-1",
+		"1.0.0", "1.0.1", "1.2.0", "1.10.0", "2",
+	}
+	for i := range ordered {
+		for j := range ordered {
+			a, _ := ParseVersion(ordered[i])
+			b, _ := ParseVersion(ordered[j])
+			if a.Compare(b) != compareInts(i, j) {
+				t.Errorf("Comparing %s with %s should give %d", ordered[i], ordered[j],
+					compareInts(i, j))
+			}
+		}
+	}
+	a, _ := ParseVersion("1.2")
+	b, _ := ParseVersion("1.2.0")
+	if a.Compare(b) != 0 {
+		t.Errorf("Versions 1.2 and 1.2.0 should be equal")
+	}
+}